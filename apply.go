@@ -0,0 +1,478 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// reloadDelay is how long we stay on the temporary theme before switching
+// back when there is no change notification to wait for.
+const reloadDelay = 1 * time.Second
+
+// Applier makes the desktop switch to an installed theme.
+//
+// GTK applications only re-read theme files when the theme name changes, so
+// Apply first switches to reload (an identical copy of theme installed under
+// another name) and then back to theme. Describe lists what Apply would do,
+// without doing it, for -dry-run.
+type Applier interface {
+	Name() string
+	Apply(theme, reload string) error
+	Describe(theme, reload string) []string
+}
+
+// applyMethods lists the values accepted by -apply-method.
+var applyMethods = []string{"auto", "gsettings", "dconf", "settings-ini", "xsettingsd", "env", "nwg-look"}
+
+// newApplier builds the applier for a -apply-method value. Several methods can
+// be combined with commas, e.g. "settings-ini,xsettingsd".
+func newApplier(method string) (Applier, error) {
+	var appliers multiApplier
+	for _, m := range strings.Split(method, ",") {
+		switch strings.TrimSpace(m) {
+		case "auto", "":
+			appliers = append(appliers, detectApplier(os.Getenv("XDG_CURRENT_DESKTOP")))
+		case "gsettings":
+			appliers = append(appliers, gnomeInterface)
+		case "dconf":
+			appliers = append(appliers, dconfApplier{})
+		case "settings-ini":
+			appliers = append(appliers, settingsIniApplier{})
+		case "xsettingsd":
+			appliers = append(appliers, xsettingsdApplier{})
+		case "env":
+			appliers = append(appliers, envApplier{})
+		case "nwg-look":
+			appliers = append(appliers, settingsIniApplier{}, dconfApplier{})
+		default:
+			return nil, fmt.Errorf("unknown apply method %q (valid: %s)", m, strings.Join(applyMethods, ", "))
+		}
+	}
+	if len(appliers) == 1 {
+		return appliers[0], nil
+	}
+	return appliers, nil
+}
+
+// detectApplier picks an applier for the desktop named in XDG_CURRENT_DESKTOP,
+// which is a colon separated list such as "ubuntu:GNOME".
+func detectApplier(currentDesktop string) Applier {
+	for _, desktop := range strings.Split(strings.ToLower(currentDesktop), ":") {
+		switch desktop {
+		case "gnome", "unity", "budgie", "pantheon", "gnome-flashback":
+			return gnomeInterface
+		case "x-cinnamon", "cinnamon":
+			return cinnamonInterface
+		case "mate":
+			return mateInterface
+		case "hyprland", "sway", "river", "wlroots", "niri", "labwc", "wayfire":
+			// No settings daemon of their own; GTK and the portal read
+			// dconf, and plain GTK apps fall back to settings.ini.
+			return multiApplier{settingsIniApplier{}, dconfApplier{}}
+		case "kde":
+			// Plasma mirrors GTK settings into settings.ini and, on X11,
+			// xsettingsd.
+			if xsettingsdRunning() {
+				return multiApplier{settingsIniApplier{}, xsettingsdApplier{}}
+			}
+			return settingsIniApplier{}
+		}
+	}
+
+	if xsettingsdRunning() {
+		return multiApplier{settingsIniApplier{}, xsettingsdApplier{}}
+	}
+	return gnomeInterface
+}
+
+// multiApplier applies through several mechanisms, continuing past failures.
+type multiApplier []Applier
+
+func (m multiApplier) Name() string {
+	names := make([]string, len(m))
+	for i, a := range m {
+		names[i] = a.Name()
+	}
+	return strings.Join(names, "+")
+}
+
+func (m multiApplier) Apply(theme, reload string) error {
+	var errs []error
+	for _, a := range m {
+		if err := a.Apply(theme, reload); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", a.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m multiApplier) Describe(theme, reload string) []string {
+	var steps []string
+	for _, a := range m {
+		steps = append(steps, a.Describe(theme, reload)...)
+	}
+	return steps
+}
+
+// gsettingsApplier sets the gtk-theme key of a GSettings schema. The key is
+// written through dconf over D-Bus and each switch is confirmed by the change
+// signals; the gsettings tool (with a fixed delay) is only used when the bus
+// is unavailable.
+type gsettingsApplier struct {
+	schema    string
+	dconfPath string // where the schema is stored, which need not follow its ID
+}
+
+// The interface schemas of the desktops with a GSettings applier. MATE's
+// schema ID drops the "desktop" that its dconf path keeps.
+var (
+	gnomeInterface    = gsettingsApplier{schema: "org.gnome.desktop.interface", dconfPath: "/org/gnome/desktop/interface/"}
+	cinnamonInterface = gsettingsApplier{schema: "org.cinnamon.desktop.interface", dconfPath: "/org/cinnamon/desktop/interface/"}
+	mateInterface     = gsettingsApplier{schema: "org.mate.interface", dconfPath: "/org/mate/desktop/interface/"}
+)
+
+func (g gsettingsApplier) Name() string { return "gsettings" }
+
+func (g gsettingsApplier) Apply(theme, reload string) error {
+	err := dconfToggle(g.dconfPath+"gtk-theme", theme, reload)
+	if !errors.Is(err, errBusUnavailable) {
+		return err
+	}
+
+	return toggle(theme, reload, func(name string) error {
+		return runCommand("gsettings", "set", g.schema, "gtk-theme", name)
+	})
+}
+
+func (g gsettingsApplier) Describe(theme, reload string) []string {
+	return []string{
+		"gsettings set " + g.schema + " gtk-theme " + reload,
+		"gsettings set " + g.schema + " gtk-theme " + theme,
+	}
+}
+
+// dconfApplier writes the GNOME interface key straight into dconf, which works
+// on compositors that ship without the GSettings schemas (nwg-look style).
+type dconfApplier struct{}
+
+func (dconfApplier) Name() string { return "dconf" }
+
+func (dconfApplier) Apply(theme, reload string) error {
+	const key = "/org/gnome/desktop/interface/gtk-theme"
+	err := dconfToggle(key, theme, reload)
+	if !errors.Is(err, errBusUnavailable) {
+		return err
+	}
+
+	return toggle(theme, reload, func(name string) error {
+		return runCommand("dconf", "write", key, strconv.Quote(name))
+	})
+}
+
+func (dconfApplier) Describe(theme, reload string) []string {
+	const key = "/org/gnome/desktop/interface/gtk-theme"
+	return []string{
+		"dconf write " + key + " " + shellQuote(strconv.Quote(reload)),
+		"dconf write " + key + " " + shellQuote(strconv.Quote(theme)),
+	}
+}
+
+// settingsIniApplier sets gtk-theme-name in the GTK 3 and GTK 4 settings.ini
+// files. GTK reads these at startup, so no reload toggle is needed.
+type settingsIniApplier struct{}
+
+func (settingsIniApplier) Name() string { return "settings-ini" }
+
+func (settingsIniApplier) Apply(theme, reload string) error {
+	configDir, err := userConfigDir()
+	if err != nil {
+		return err
+	}
+	for _, dir := range []string{"gtk-3.0", "gtk-4.0"} {
+		path := filepath.Join(configDir, dir, "settings.ini")
+		if err := updateKeyFile(path, "Settings", "gtk-theme-name", theme); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (settingsIniApplier) Describe(theme, reload string) []string {
+	configDir, err := userConfigDir()
+	if err != nil {
+		return []string{"error: " + err.Error()}
+	}
+	var steps []string
+	for _, dir := range []string{"gtk-3.0", "gtk-4.0"} {
+		path := filepath.Join(configDir, dir, "settings.ini")
+		steps = append(steps, fmt.Sprintf("set [Settings] gtk-theme-name=%s in %s", theme, path))
+	}
+	return steps
+}
+
+// xsettingsdApplier updates Net/ThemeName in the xsettingsd configuration and
+// signals the daemon to reload it.
+type xsettingsdApplier struct{}
+
+func (xsettingsdApplier) Name() string { return "xsettingsd" }
+
+func (xsettingsdApplier) Apply(theme, reload string) error {
+	path, err := xsettingsdConfigPath()
+	if err != nil {
+		return err
+	}
+	return toggle(theme, reload, func(name string) error {
+		if err := updateXSettingsdConfig(path, "Net/ThemeName", strconv.Quote(name)); err != nil {
+			return err
+		}
+		return signalProcesses("xsettingsd", syscall.SIGHUP)
+	})
+}
+
+func (xsettingsdApplier) Describe(theme, reload string) []string {
+	path, err := xsettingsdConfigPath()
+	if err != nil {
+		return []string{"error: " + err.Error()}
+	}
+	var steps []string
+	for _, name := range []string{reload, theme} {
+		steps = append(steps,
+			fmt.Sprintf("set Net/ThemeName %q in %s", name, path),
+			"pkill -HUP -x xsettingsd")
+	}
+	return steps
+}
+
+// envApplier exports GTK_THEME through systemd's environment.d, which forces
+// the theme for every GTK application started in the next session.
+type envApplier struct{}
+
+func (envApplier) Name() string { return "env" }
+
+func (envApplier) Apply(theme, reload string) error {
+	configDir, err := userConfigDir()
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(configDir, "environment.d", "60-material-gtk.conf")
+	content := fmt.Sprintf("# Written by material-gtk\nGTK_THEME=%s\n", theme)
+	if err := writeFileAtomic(path, []byte(content), 0644); err != nil {
+		return err
+	}
+	fmt.Printf("   GTK_THEME=%s written to %s (takes effect on next login)\n", theme, path)
+	return nil
+}
+
+func (envApplier) Describe(theme, reload string) []string {
+	configDir, err := userConfigDir()
+	if err != nil {
+		return []string{"error: " + err.Error()}
+	}
+	path := filepath.Join(configDir, "environment.d", "60-material-gtk.conf")
+	return []string{fmt.Sprintf("write GTK_THEME=%s to %s", theme, path)}
+}
+
+// toggle switches to reload and then back to theme. The switch back is
+// attempted even if the first switch failed.
+func toggle(theme, reload string, set func(name string) error) error {
+	if err := set(reload); err != nil {
+		if errBack := set(theme); errBack != nil {
+			return errors.Join(
+				fmt.Errorf("failed to switch to %s: %w", reload, err),
+				fmt.Errorf("failed to switch back to %s: %w", theme, errBack))
+		}
+		return fmt.Errorf("failed to switch to %s: %w", reload, err)
+	}
+
+	time.Sleep(reloadDelay)
+
+	if err := set(theme); err != nil {
+		return fmt.Errorf("failed to switch back to %s: %w", theme, err)
+	}
+	return nil
+}
+
+func runCommand(name string, args ...string) error {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%s: %w: %s", name, err, msg)
+		}
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// shellQuote quotes s for display as a single shell word.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// userConfigDir returns $XDG_CONFIG_HOME, defaulting to ~/.config.
+func userConfigDir() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return dir, nil
+	}
+	home, err := homeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config"), nil
+}
+
+// updateKeyFile sets key in [section] of a GLib key file, preserving every
+// other line. Missing files and sections are created.
+func updateKeyFile(path, section, key, value string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	var lines []string
+	if len(data) > 0 {
+		lines = strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	}
+
+	header := "[" + section + "]"
+	entry := key + "=" + value
+	inSection, done := false, false
+	sectionEnd := -1 // index after the last non-blank line of the section
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			inSection = trimmed == header
+			if inSection {
+				sectionEnd = i + 1
+			}
+			continue
+		}
+		if inSection && trimmed != "" {
+			sectionEnd = i + 1
+			if k, _, ok := strings.Cut(trimmed, "="); ok && strings.TrimSpace(k) == key {
+				lines[i] = entry
+				done = true
+				break
+			}
+		}
+	}
+
+	if !done {
+		switch {
+		case sectionEnd >= 0:
+			lines = append(lines[:sectionEnd], append([]string{entry}, lines[sectionEnd:]...)...)
+		default:
+			if len(lines) > 0 {
+				lines = append(lines, "")
+			}
+			lines = append(lines, header, entry)
+		}
+	}
+
+	return writeFileAtomic(path, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
+
+// xsettingsdConfigPath returns the configuration file xsettingsd reads,
+// preferring an existing one.
+func xsettingsdConfigPath() (string, error) {
+	configDir, err := userConfigDir()
+	if err != nil {
+		return "", err
+	}
+	home, err := homeDir()
+	if err != nil {
+		return "", err
+	}
+
+	xdgPath := filepath.Join(configDir, "xsettingsd", "xsettingsd.conf")
+	for _, path := range []string{xdgPath, filepath.Join(home, ".xsettingsd")} {
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return xdgPath, nil
+}
+
+// updateXSettingsdConfig sets a setting in xsettingsd's "Name value" format.
+func updateXSettingsdConfig(path, setting, value string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	var buf bytes.Buffer
+	found := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if fields := strings.Fields(line); len(fields) > 0 && fields[0] == setting {
+			line = setting + " " + value
+			found = true
+		}
+		buf.WriteString(line + "\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if !found {
+		buf.WriteString(setting + " " + value + "\n")
+	}
+
+	return writeFileAtomic(path, buf.Bytes(), 0644)
+}
+
+// findProcesses returns the PIDs of the current user's processes named comm.
+func findProcesses(comm string) []int {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil
+	}
+
+	uid := os.Getuid()
+	var pids []int
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join("/proc", e.Name(), "comm"))
+		if err != nil || strings.TrimSpace(string(data)) != comm {
+			continue
+		}
+		if info, err := e.Info(); err == nil {
+			if st, ok := info.Sys().(*syscall.Stat_t); ok && int(st.Uid) != uid {
+				continue
+			}
+		}
+		pids = append(pids, pid)
+	}
+	sort.Ints(pids)
+	return pids
+}
+
+func xsettingsdRunning() bool {
+	return len(findProcesses("xsettingsd")) > 0
+}
+
+// signalProcesses sends sig to every process of the current user named comm.
+func signalProcesses(comm string, sig syscall.Signal) error {
+	pids := findProcesses(comm)
+	if len(pids) == 0 {
+		return fmt.Errorf("%s is not running", comm)
+	}
+	for _, pid := range pids {
+		if err := syscall.Kill(pid, sig); err != nil {
+			return fmt.Errorf("failed to signal %s (pid %d): %w", comm, pid, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestUpdateKeyFile(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string // "" means the file does not exist
+		want   string
+	}{
+		{
+			name:   "missing file",
+			before: "",
+			want:   "[Settings]\ngtk-theme-name=Material\n",
+		},
+		{
+			name:   "missing section",
+			before: "[Other]\nfoo=bar\n",
+			want:   "[Other]\nfoo=bar\n\n[Settings]\ngtk-theme-name=Material\n",
+		},
+		{
+			name:   "key in another section",
+			before: "[Other]\ngtk-theme-name=Keep\n\n[Settings]\ngtk-font-name=Sans 10\n",
+			want:   "[Other]\ngtk-theme-name=Keep\n\n[Settings]\ngtk-font-name=Sans 10\ngtk-theme-name=Material\n",
+		},
+		{
+			name:   "existing key replaced, other lines kept",
+			before: "# comment\n[Settings]\ngtk-icon-theme-name=Papirus\ngtk-theme-name = Adwaita\ngtk-font-name=Sans 10\n\n[Extra]\nx=1\n",
+			want:   "# comment\n[Settings]\ngtk-icon-theme-name=Papirus\ngtk-theme-name=Material\ngtk-font-name=Sans 10\n\n[Extra]\nx=1\n",
+		},
+		{
+			name:   "appended at the end of the section, before the next one",
+			before: "[Settings]\ngtk-font-name=Sans 10\n\n[Extra]\nx=1\n",
+			want:   "[Settings]\ngtk-font-name=Sans 10\ngtk-theme-name=Material\n\n[Extra]\nx=1\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "gtk-3.0", "settings.ini")
+			if tt.before != "" {
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(tt.before), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := updateKeyFile(path, "Settings", "gtk-theme-name", "Material"); err != nil {
+				t.Fatalf("updateKeyFile: %v", err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateXSettingsdConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string // "" means the file does not exist
+		want   string
+	}{
+		{
+			name:   "missing file",
+			before: "",
+			want:   "Net/ThemeName \"Material\"\n",
+		},
+		{
+			name:   "existing setting replaced, other lines kept",
+			before: "# xsettingsd\nNet/IconThemeName \"Papirus\"\nNet/ThemeName \"Adwaita\"\nXft/DPI 98304\n",
+			want:   "# xsettingsd\nNet/IconThemeName \"Papirus\"\nNet/ThemeName \"Material\"\nXft/DPI 98304\n",
+		},
+		{
+			name:   "setting appended",
+			before: "Xft/DPI 98304\n",
+			want:   "Xft/DPI 98304\nNet/ThemeName \"Material\"\n",
+		},
+		{
+			name:   "prefix of another setting is not matched",
+			before: "Net/ThemeNameExtra 1\n",
+			want:   "Net/ThemeNameExtra 1\nNet/ThemeName \"Material\"\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "xsettingsd", "xsettingsd.conf")
+			if tt.before != "" {
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(tt.before), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := updateXSettingsdConfig(path, "Net/ThemeName", `"Material"`); err != nil {
+				t.Fatalf("updateXSettingsdConfig: %v", err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectApplier(t *testing.T) {
+	if xsettingsdRunning() {
+		t.Skip("xsettingsd is running, which changes the fallbacks")
+	}
+	tests := []struct {
+		desktop string
+		want    Applier
+	}{
+		{"ubuntu:GNOME", gnomeInterface},
+		{"GNOME", gnomeInterface},
+		{"X-Cinnamon", cinnamonInterface},
+		{"MATE", mateInterface},
+		{"Hyprland", multiApplier{settingsIniApplier{}, dconfApplier{}}},
+		{"sway", multiApplier{settingsIniApplier{}, dconfApplier{}}},
+		{"KDE", settingsIniApplier{}},
+		{"", gnomeInterface},
+		{"SomethingElse", gnomeInterface},
+	}
+	for _, tt := range tests {
+		if got := detectApplier(tt.desktop); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("detectApplier(%q) = %#v, want %#v", tt.desktop, got, tt.want)
+		}
+	}
+}
+
+func TestGsettingsApplierDconfPath(t *testing.T) {
+	tests := []struct {
+		applier gsettingsApplier
+		key     string
+	}{
+		{gnomeInterface, "/org/gnome/desktop/interface/gtk-theme"},
+		{cinnamonInterface, "/org/cinnamon/desktop/interface/gtk-theme"},
+		// The schema is org.mate.interface, but its path keeps "desktop".
+		{mateInterface, "/org/mate/desktop/interface/gtk-theme"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.applier.schema, func(t *testing.T) {
+			addr := startTestBus(t)
+			setTimeouts(t, 5*time.Second, 100*time.Millisecond)
+			f := &fakeDconf{notify: true}
+			startFakeDconf(t, addr, f, false)
+
+			if err := tt.applier.Apply("Theme", "ThemeTemp"); err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+			if got, want := f.writtenKeys(), []string{tt.key, tt.key}; !reflect.DeepEqual(got, want) {
+				t.Errorf("wrote %q, want %q", got, want)
+			}
+		})
+	}
+}
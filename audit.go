@@ -0,0 +1,242 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+
+	"material-gtk/pkg/m3color"
+)
+
+// runAudit implements the audit subcommand: it checks every text/background
+// pair of the generated CSS, or of an existing file with -css, against WCAG
+// 2.x and APCA thresholds and fails if any pair is below them.
+func runAudit(args []string) error {
+	fs := flag.NewFlagSet("audit", flag.ExitOnError)
+	var opts options
+	opts.register(fs)
+	cssPath := fs.String("css", "", "Audit this GTK CSS file instead of generating one")
+	minWCAG := fs.Float64("min-wcag", 4.5, "Minimum WCAG 2.x contrast ratio (4.5 is AA for body text)")
+	minAPCA := fs.Float64("min-apca", 60, "Minimum APCA |Lc| (60 for body text, 0 to skip)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s audit [options] [R,G,B]\n\nOptions:\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	var css string
+	if *cssPath != "" {
+		data, err := os.ReadFile(*cssPath)
+		if err != nil {
+			return err
+		}
+		css = string(data)
+	} else {
+		if _, err := opts.loadWithSeedArg(fs); err != nil {
+			return err
+		}
+		if opts.seed == "" && opts.seedFrom == "" {
+			return fmt.Errorf("audit needs a seed (R,G,B, -rgb or -seed-from) or -css")
+		}
+		spec, err := opts.spec()
+		if err != nil {
+			return err
+		}
+		if css, err = generateGTKTheme(spec); err != nil {
+			return err
+		}
+	}
+
+	results := auditCSS(parseCSSRules(css), *minWCAG, *minAPCA)
+	failed := printAudit(os.Stdout, results)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d color pairs are below the contrast thresholds", failed, len(results))
+	}
+	return nil
+}
+
+// cssRule is one selector block of a stylesheet with its declarations.
+type cssRule struct {
+	selector string
+	props    map[string]string
+}
+
+var cssComment = regexp.MustCompile(`(?s)/\*.*?\*/`)
+
+// parseCSSRules splits a flat stylesheet, such as the generated one, into
+// rules. Selector lists are split so each selector is its own rule; @-rules
+// without a block are skipped.
+func parseCSSRules(css string) []cssRule {
+	css = cssComment.ReplaceAllString(css, "")
+
+	var rules []cssRule
+	for {
+		open := strings.IndexByte(css, '{')
+		if open < 0 {
+			break
+		}
+		end := strings.IndexByte(css[open:], '}')
+		if end < 0 {
+			break
+		}
+		head, body := css[:open], css[open+1:open+end]
+		css = css[open+end+1:]
+
+		// Statements such as @define-color end in ';' before the selector.
+		if i := strings.LastIndexByte(head, ';'); i >= 0 {
+			head = head[i+1:]
+		}
+		props := make(map[string]string)
+		for _, decl := range strings.Split(body, ";") {
+			name, value, ok := strings.Cut(decl, ":")
+			if ok {
+				props[strings.TrimSpace(name)] = strings.TrimSpace(value)
+			}
+		}
+		for _, sel := range strings.Split(head, ",") {
+			if sel = strings.Join(strings.Fields(sel), " "); sel != "" {
+				rules = append(rules, cssRule{selector: sel, props: props})
+			}
+		}
+	}
+	return rules
+}
+
+// auditResult is the contrast of one rule's text on its background.
+type auditResult struct {
+	selector                 string
+	fg, bg                   color.RGBA
+	fgInherited, bgInherited bool
+	wcag, apca               float64
+	pass                     bool
+}
+
+// auditCSS checks every rule that sets a text or background color. A color
+// the rule leaves out is inherited from the nearest rule it refines, and
+// finally from window.
+func auditCSS(rules []cssRule, minWCAG, minAPCA float64) []auditResult {
+	bySelector := make(map[string]cssRule)
+	for _, r := range rules {
+		if existing, ok := bySelector[r.selector]; ok {
+			merged := make(map[string]string)
+			for k, v := range existing.props {
+				merged[k] = v
+			}
+			for k, v := range r.props {
+				merged[k] = v
+			}
+			r.props = merged
+		}
+		bySelector[r.selector] = r
+	}
+
+	var results []auditResult
+	seen := make(map[string]bool)
+	for _, r := range rules {
+		if seen[r.selector] || r.selector == "*" {
+			continue
+		}
+		seen[r.selector] = true
+		r = bySelector[r.selector]
+
+		_, hasFG := cssColor(r.props["color"])
+		_, hasBG := cssColor(r.props["background-color"])
+		if !hasFG && !hasBG {
+			continue
+		}
+		fg, fgFound := inheritedColor(bySelector, r.selector, "color")
+		bg, bgFound := inheritedColor(bySelector, r.selector, "background-color")
+		if !fgFound || !bgFound {
+			continue
+		}
+
+		res := auditResult{
+			selector:    r.selector,
+			fg:          fg,
+			bg:          bg,
+			fgInherited: !hasFG,
+			bgInherited: !hasBG,
+			wcag:        m3color.ContrastRatio(fg, bg),
+			apca:        m3color.APCAContrast(fg, bg),
+		}
+		res.pass = res.wcag >= minWCAG && (minAPCA <= 0 || math.Abs(res.apca) >= minAPCA)
+		results = append(results, res)
+	}
+	return results
+}
+
+// inheritedColor looks prop up on selector and then on the selectors it
+// refines: "notebook tab:checked" falls back to "notebook tab", "notebook"
+// and window.
+func inheritedColor(rules map[string]cssRule, selector, prop string) (color.RGBA, bool) {
+	for _, sel := range selectorAncestors(selector) {
+		if c, ok := cssColor(rules[sel].props[prop]); ok {
+			return c, true
+		}
+	}
+	return color.RGBA{}, false
+}
+
+// selectorRefinement matches the last part of a selector: a class or
+// pseudo-class, or else the last descendant or child, whose own refinements
+// have been stripped already.
+var selectorRefinement = regexp.MustCompile(`([.:][\w-]+(\([^)]*\))?|(\s*[>+~]\s*|\s+)[^\s.:>+~]+)$`)
+
+func selectorAncestors(selector string) []string {
+	chain := []string{selector}
+	for {
+		parent := strings.TrimSpace(selectorRefinement.ReplaceAllString(selector, ""))
+		if parent == selector || parent == "" {
+			break
+		}
+		chain = append(chain, parent)
+		selector = parent
+	}
+	if selector == "window" {
+		return chain
+	}
+	return append(chain, "window")
+}
+
+// cssColor parses #rgb and #rrggbb values; other notations are not audited.
+func cssColor(value string) (color.RGBA, bool) {
+	hex := strings.TrimPrefix(strings.TrimSpace(value), "#")
+	if len(hex) == len(value) {
+		return color.RGBA{}, false
+	}
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	c, err := parseSeed("#" + hex)
+	return c, err == nil
+}
+
+// printAudit writes the results as a table and returns how many failed.
+func printAudit(w io.Writer, results []auditResult) int {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SELECTOR\tTEXT\tBACKGROUND\tWCAG\tAPCA Lc\tRESULT")
+	failed := 0
+	for _, r := range results {
+		fg, bg := colorToHex(r.fg), colorToHex(r.bg)
+		if r.fgInherited {
+			fg += " (inherited)"
+		}
+		if r.bgInherited {
+			bg += " (inherited)"
+		}
+		result := "ok"
+		if !r.pass {
+			result = "FAIL"
+			failed++
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%.2f:1\t%.1f\t%s\n", r.selector, fg, bg, r.wcag, r.apca, result)
+	}
+	tw.Flush()
+	return failed
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"image/color"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCSSRules(t *testing.T) {
+	css := `/* generated */
+@define-color primary #336699;
+@define-color onPrimary #ffffff;
+window { color: #111111; background-color: #fafafa; }
+button, .suggested-action,
+  headerbar   button:hover { color: @onPrimary; }
+@define-color accent #ff0000; entry { background-color: #eeeeee }
+`
+	got := parseCSSRules(css)
+	want := []cssRule{
+		{"window", map[string]string{"color": "#111111", "background-color": "#fafafa"}},
+		{"button", map[string]string{"color": "@onPrimary"}},
+		{".suggested-action", map[string]string{"color": "@onPrimary"}},
+		{"headerbar button:hover", map[string]string{"color": "@onPrimary"}},
+		{"entry", map[string]string{"background-color": "#eeeeee"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseCSSRules =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestSelectorAncestors(t *testing.T) {
+	tests := []struct {
+		selector string
+		want     []string
+	}{
+		{"notebook tab:checked", []string{"notebook tab:checked", "notebook tab", "notebook", "window"}},
+		{"button.suggested-action:hover", []string{"button.suggested-action:hover", "button.suggested-action", "button", "window"}},
+		{"row:nth-child(2)", []string{"row:nth-child(2)", "row", "window"}},
+		{"window", []string{"window"}},
+		{"box > button", []string{"box > button", "box", "window"}},
+	}
+	for _, tt := range tests {
+		if got := selectorAncestors(tt.selector); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("selectorAncestors(%q) = %q, want %q", tt.selector, got, tt.want)
+		}
+	}
+}
+
+func TestInheritedColor(t *testing.T) {
+	rules := make(map[string]cssRule)
+	for _, r := range parseCSSRules(`
+window { color: #111111; background-color: #ffffff; }
+notebook { background-color: #eeeeee; }
+notebook tab { color: #222222; }
+`) {
+		rules[r.selector] = r
+	}
+	tests := []struct {
+		prop string
+		want color.RGBA
+	}{
+		{"color", color.RGBA{0x22, 0x22, 0x22, 255}},            // from notebook tab
+		{"background-color", color.RGBA{0xee, 0xee, 0xee, 255}}, // from notebook
+		{"border-color", color.RGBA{}},
+	}
+	for _, tt := range tests {
+		got, ok := inheritedColor(rules, "notebook tab:checked", tt.prop)
+		if got != tt.want || ok != (tt.want != color.RGBA{}) {
+			t.Errorf("inheritedColor(%s) = %v, %v; want %v", tt.prop, got, ok, tt.want)
+		}
+	}
+}
+
+func TestCSSColor(t *testing.T) {
+	tests := []struct {
+		value string
+		want  color.RGBA
+		ok    bool
+	}{
+		{"#336699", color.RGBA{0x33, 0x66, 0x99, 255}, true},
+		{" #FFF ", color.RGBA{255, 255, 255, 255}, true},
+		{"#abc", color.RGBA{0xaa, 0xbb, 0xcc, 255}, true},
+		{"336699", color.RGBA{}, false},
+		{"@primary", color.RGBA{}, false},
+		{"rgb(1, 2, 3)", color.RGBA{}, false},
+		{"#12345", color.RGBA{}, false},
+		{"", color.RGBA{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := cssColor(tt.value)
+		if ok != tt.ok || (ok && got != tt.want) {
+			t.Errorf("cssColor(%q) = %v, %v; want %v, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestRunAuditCSS(t *testing.T) {
+	tests := []struct {
+		name string
+		css  string
+		err  string
+	}{
+		{"passing", "window { color: #000000; background-color: #ffffff; }\n", ""},
+		{"failing pair", "window { color: #000000; background-color: #ffffff; }\nlabel.dim { color: #aaaaaa; }\n", "1 of 2 color pairs"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "gtk.css")
+			if err := os.WriteFile(path, []byte(tt.css), 0644); err != nil {
+				t.Fatal(err)
+			}
+			err := runAudit([]string{"-css", path})
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("runAudit: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("runAudit = %v, want an error containing %q", err, tt.err)
+			}
+		})
+	}
+}
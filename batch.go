@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// batchEntry is one theme of a batch file. Variant and mode default to the
+// command's -variant and -mode.
+type batchEntry struct {
+	Name    string `json:"name"`
+	Seed    string `json:"seed"`
+	Variant string `json:"variant,omitempty"`
+	Mode    string `json:"mode,omitempty"`
+}
+
+// batchResult is the outcome of generating one entry.
+type batchResult struct {
+	dir    string
+	writes []plannedWrite // only with -dry-run
+	err    error
+}
+
+// runBatch implements the batch subcommand: it generates one theme directory
+// per entry of a CSV or JSON file, several at a time. A failing entry is
+// reported and the rest of the batch still runs; the command exits non-zero
+// if any entry failed.
+func runBatch(args []string) error {
+	fs := flag.NewFlagSet("batch", flag.ExitOnError)
+	var opts options
+	opts.register(fs)
+	inputFormat := fs.String("input-format", "", "Format of the batch file: csv or json (default: from the extension)")
+	jobs := fs.Int("jobs", runtime.NumCPU(), "Number of themes generated at once")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s batch [options] FILE\n\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "FILE lists themes as CSV with a name,seed[,variant][,mode] header, or as a\n")
+		fmt.Fprintf(fs.Output(), "JSON array of {\"name\", \"seed\", \"variant\", \"mode\"} objects. Use - for stdin.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("batch needs exactly one input file")
+	}
+	if *jobs < 1 {
+		return fmt.Errorf("-jobs must be positive")
+	}
+	if _, err := opts.load(fs); err != nil {
+		return err
+	}
+
+	entries, err := readBatchFile(fs.Arg(0), *inputFormat)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("%s lists no themes", fs.Arg(0))
+	}
+	themesDir, err := opts.resolveThemesDir()
+	if err != nil {
+		return fmt.Errorf("failed to locate themes directory: %w", err)
+	}
+
+	results := make([]batchResult, len(entries))
+	seen := make(map[string]int)
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for range min(*jobs, len(entries)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				results[i] = generateBatchEntry(opts, entries[i], themesDir)
+			}
+		}()
+	}
+	for i, e := range entries {
+		// Two entries writing the same directory would race each other.
+		if first, ok := seen[e.Name]; ok {
+			results[i].err = fmt.Errorf("duplicate theme name (also entry %d)", first+1)
+			continue
+		}
+		seen[e.Name] = i
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	var writes []plannedWrite
+	failed := 0
+	for i, r := range results {
+		name := entries[i].Name
+		if name == "" {
+			name = fmt.Sprintf("entry %d", i+1)
+		}
+		switch {
+		case r.err != nil:
+			failed++
+			fmt.Fprintf(os.Stderr, "❌ %s: %v\n", name, r.err)
+		case opts.dryRun:
+			writes = append(writes, r.writes...)
+		default:
+			fmt.Printf("✅ %s installed to %s\n", name, r.dir)
+		}
+	}
+	if opts.dryRun {
+		if err := printDryRun(os.Stdout, writes, nil); err != nil {
+			return fmt.Errorf("dry run failed: %w", err)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d themes failed", failed, len(entries))
+	}
+	return nil
+}
+
+// generateBatchEntry renders and installs one theme. opts is a copy, so the
+// entry's settings do not leak into other entries.
+func generateBatchEntry(opts options, e batchEntry, themesDir string) batchResult {
+	if err := checkThemeName(e.Name); err != nil {
+		return batchResult{err: err}
+	}
+	if e.Seed == "" {
+		return batchResult{err: fmt.Errorf("missing seed")}
+	}
+	opts.seed, opts.seedFrom = e.Seed, ""
+	if e.Variant != "" {
+		opts.variant = e.Variant
+	}
+	if e.Mode != "" {
+		opts.mode = e.Mode
+	}
+
+	spec, err := opts.spec()
+	if err != nil {
+		return batchResult{err: err}
+	}
+	css, err := generateGTKTheme(spec)
+	if err != nil {
+		return batchResult{err: err}
+	}
+	files, err := themeFiles(e.Name, css, spec, nil)
+	if err != nil {
+		return batchResult{err: err}
+	}
+	if opts.dryRun {
+		return batchResult{writes: themeWrites(themesDir, e.Name, files)}
+	}
+	dir, err := installTheme(themesDir, e.Name, files)
+	if err != nil {
+		return batchResult{err: fmt.Errorf("failed to install theme: %w", err)}
+	}
+	return batchResult{dir: dir}
+}
+
+// readBatchFile reads the entries of a batch file, or of stdin for "-".
+// Without a format, .json files are read as JSON and anything else as CSV.
+func readBatchFile(path, format string) ([]batchEntry, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read batch file: %w", err)
+	}
+
+	if format == "" {
+		format = "csv"
+		if strings.EqualFold(filepath.Ext(path), ".json") {
+			format = "json"
+		}
+	}
+	switch format {
+	case "json":
+		var entries []batchEntry
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		return entries, nil
+	case "csv":
+		entries, err := parseBatchCSV(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		return entries, nil
+	}
+	return nil, fmt.Errorf("invalid input format %q (valid: csv, json)", format)
+}
+
+// parseBatchCSV reads CSV whose header names the columns: name and seed are
+// required, variant and mode optional, in any order. Seeds in R,G,B form
+// must be quoted.
+func parseBatchCSV(data []byte) ([]batchEntry, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comment = '#'
+	r.TrimLeadingSpace = true
+	r.FieldsPerRecord = -1 // trailing optional columns may be left off
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "name", "seed", "variant", "mode":
+			columns[name] = i
+		default:
+			return nil, fmt.Errorf("unknown column %q (valid: name, seed, variant, mode)", name)
+		}
+	}
+	for _, required := range []string{"name", "seed"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("header has no %s column", required)
+		}
+	}
+
+	field := func(record []string, column string) string {
+		if i, ok := columns[column]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	entries := make([]batchEntry, 0, len(records)-1)
+	for _, record := range records[1:] {
+		entries = append(entries, batchEntry{
+			Name:    field(record, "name"),
+			Seed:    field(record, "seed"),
+			Variant: field(record, "variant"),
+			Mode:    field(record, "mode"),
+		})
+	}
+	return entries, nil
+}
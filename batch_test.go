@@ -0,0 +1,178 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckThemeName(t *testing.T) {
+	for _, name := range []string{"OmarchyTheme", "my-theme", "Theme.v2", "...", ".hidden"} {
+		if err := checkThemeName(name); err != nil {
+			t.Errorf("checkThemeName(%q) = %v", name, err)
+		}
+	}
+	for _, name := range []string{"", ".", "..", "a/b", "../x", "/abs"} {
+		if err := checkThemeName(name); err == nil {
+			t.Errorf("checkThemeName(%q) accepted it", name)
+		}
+	}
+}
+
+func TestReadBatchFile(t *testing.T) {
+	tests := []struct {
+		name, file, format, content string
+		want                        []batchEntry
+		wantErr                     string
+	}{
+		{
+			name: "csv with all columns",
+			file: "themes.csv",
+			content: "name,seed,variant,mode\n" +
+				"# a comment\n" +
+				"Ocean, \"0,105,148\", vibrant, dark\n" +
+				"Forest,#2e7d32,,\n",
+			want: []batchEntry{
+				{Name: "Ocean", Seed: "0,105,148", Variant: "vibrant", Mode: "dark"},
+				{Name: "Forest", Seed: "#2e7d32"},
+			},
+		},
+		{
+			name: "csv columns in any order, trailing ones left off",
+			file: "themes.txt",
+			// Lines starting with # are comments, so a leading hex seed is quoted.
+			content: "Seed,Mode,Name\n\"#ff0000\",light,Red\n\"#00ff00\"\n",
+			want: []batchEntry{
+				{Name: "Red", Seed: "#ff0000", Mode: "light"},
+				{Seed: "#00ff00"},
+			},
+		},
+		{
+			name:    "csv header only",
+			file:    "themes.csv",
+			content: "name,seed\n",
+			want:    []batchEntry{},
+		},
+		{
+			name:    "csv unknown column",
+			file:    "themes.csv",
+			content: "name,seed,accent\n",
+			wantErr: `unknown column "accent"`,
+		},
+		{
+			name:    "csv without seed column",
+			file:    "themes.csv",
+			content: "name,variant\nA,vibrant\n",
+			wantErr: "header has no seed column",
+		},
+		{
+			name:    "json by extension",
+			file:    "themes.JSON",
+			content: `[{"name": "Ocean", "seed": "0,105,148", "variant": "vibrant"}, {"name": "Night", "seed": "#101010", "mode": "dark"}]`,
+			want: []batchEntry{
+				{Name: "Ocean", Seed: "0,105,148", Variant: "vibrant"},
+				{Name: "Night", Seed: "#101010", Mode: "dark"},
+			},
+		},
+		{
+			name:    "json by -input-format",
+			file:    "themes.list",
+			format:  "json",
+			content: `[{"name": "A", "seed": "#000000"}]`,
+			want:    []batchEntry{{Name: "A", Seed: "#000000"}},
+		},
+		{
+			name:    "malformed json",
+			file:    "themes.json",
+			content: `{"name": "A"}`,
+			wantErr: "failed to parse",
+		},
+		{
+			name:    "unknown format",
+			file:    "themes.csv",
+			format:  "yaml",
+			wantErr: `invalid input format "yaml"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := readBatchFile(path, tt.format)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("entries = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := readBatchFile(filepath.Join(t.TempDir(), "missing.csv"), ""); err == nil {
+		t.Error("reading a missing file succeeded")
+	}
+}
+
+func TestRunBatchPartialFailure(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "config.toml")
+	if err := os.WriteFile(config, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	themesDir := filepath.Join(dir, "themes")
+	batch := filepath.Join(dir, "themes.csv")
+	if err := os.WriteFile(batch, []byte("name,seed,variant\n"+
+		"Good,#1c2027,\n"+
+		"..,#ff0000,\n"+
+		"BadSeed,not-a-color,\n"+
+		"BadVariant,#00ff00,sparkly\n"+
+		"Good,#0000ff,\n"+
+		",#0000ff,\n"+
+		"Also Good,\"0,105,148\",vibrant\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := runBatch([]string{"-config", config, "-themes-dir", themesDir, "-jobs", "2", batch})
+	if err == nil || err.Error() != "5 of 7 themes failed" {
+		t.Fatalf("runBatch = %v, want 5 of 7 themes failed", err)
+	}
+
+	for _, name := range []string{"Good", "Also Good"} {
+		if _, err := os.Stat(filepath.Join(themesDir, name, "gtk-3.0", "gtk.css")); err != nil {
+			t.Errorf("%s was not installed: %v", name, err)
+		}
+	}
+	// Only the good entries wrote anything, and nothing escaped themesDir.
+	entries, err := os.ReadDir(themesDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if want := []string{"Also Good", "Good"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("themes dir holds %q, want %q", names, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "gtk-3.0")); err == nil {
+		t.Error("the .. entry was installed outside the themes directory")
+	}
+	// The duplicate did not overwrite the first entry.
+	css, err := os.ReadFile(filepath.Join(themesDir, "Good", "gtk-3.0", "gtk.css"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(css), "RGB(28,32,39)") {
+		t.Error("the duplicate Good entry replaced the first one")
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// fileConfig mirrors ~/.config/material-gtk/config.toml. Pointer fields tell
+// an unset value apart from its zero value.
+//
+//	seed = "#1c2027"
+//	# seed_from = "wallpaper"
+//	# wallpaper = "~/Pictures/wallpaper.png"
+//	variant = "vibrant"
+//	mode = "dark"
+//	contrast = 0.5
+//	theme_name = "OmarchyTheme"
+//	apply = true
+//	apply_method = "auto"
+//	reproducible = true
+//
+//	[outputs]
+//	gtk3 = "~/.config/gtk-3.0/gtk.css"
+//	json = "~/.cache/material-gtk/scheme.json"
+//
+//	[[custom_colors]]
+//	name = "link"
+//	color = "#1a73e8"
+//	harmonize = true
+//
+//	[schedule]
+//	light_at = "07:00"
+//	dark_at = "19:00"
+//	# latitude = 48.2
+//	# longitude = 16.4
+type fileConfig struct {
+	Seed         string            `toml:"seed"`
+	SeedFrom     string            `toml:"seed_from"`
+	Wallpaper    string            `toml:"wallpaper"`
+	Variant      string            `toml:"variant"`
+	Mode         string            `toml:"mode"`
+	Contrast     *float64          `toml:"contrast"`
+	ThemeName    string            `toml:"theme_name"`
+	ThemesDir    string            `toml:"themes_dir"`
+	Apply        *bool             `toml:"apply"`
+	ApplyMethod  string            `toml:"apply_method"`
+	Reproducible *bool             `toml:"reproducible"`
+	Outputs      map[string]string `toml:"outputs"`
+	Schedule     scheduleConfig    `toml:"schedule"`
+	Custom       []customConfig    `toml:"custom_colors"`
+}
+
+// customConfig is one [[custom_colors]] entry, also used in theme metadata.
+type customConfig struct {
+	Name      string `toml:"name" json:"name"`
+	Color     string `toml:"color" json:"color"`
+	Harmonize bool   `toml:"harmonize" json:"harmonize,omitempty"`
+}
+
+// scheduleConfig is the [schedule] table used by the daemon subcommand.
+type scheduleConfig struct {
+	LightAt   string   `toml:"light_at"`
+	DarkAt    string   `toml:"dark_at"`
+	Latitude  *float64 `toml:"latitude"`
+	Longitude *float64 `toml:"longitude"`
+}
+
+// defaultConfigPath returns $XDG_CONFIG_HOME/material-gtk/config.toml.
+func defaultConfigPath() (string, error) {
+	configDir, err := userConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "material-gtk", "config.toml"), nil
+}
+
+// loadConfig reads a config file. A missing file is only an error when the
+// path was given explicitly; otherwise an empty config is returned.
+func loadConfig(path string, explicit bool) (*fileConfig, error) {
+	cfg := &fileConfig{}
+	meta, err := toml.DecodeFile(path, cfg)
+	if err != nil {
+		if os.IsNotExist(err) && !explicit {
+			return cfg, nil
+		}
+		return nil, fmt.Errorf("failed to read config %s: %w", path, err)
+	}
+
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, len(undecoded))
+		for i, k := range undecoded {
+			keys[i] = k.String()
+		}
+		return nil, fmt.Errorf("unknown keys in config %s: %s", path, strings.Join(keys, ", "))
+	}
+
+	for target, out := range cfg.Outputs {
+		if _, ok := outputTargets[target]; !ok {
+			return nil, fmt.Errorf("unknown output target %q in config %s (valid: %s)", target, path, strings.Join(outputTargetNames(), ", "))
+		}
+		if cfg.Outputs[target], err = expandHome(out); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.ThemesDir, err = expandHome(cfg.ThemesDir); err != nil {
+		return nil, err
+	}
+	if cfg.Wallpaper, err = expandHome(cfg.Wallpaper); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// expandHome replaces a leading ~/ with the user's home directory.
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := homeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, path[1:]), nil
+}
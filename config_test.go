@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// loadTestOptions parses args as the main command would, with the config
+// file holding config.
+func loadTestOptions(t *testing.T, config string, args ...string) options {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	var opts options
+	opts.register(fs)
+	if err := fs.Parse(append([]string{"-config", path}, args...)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := opts.loadWithSeedArg(fs); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	return opts
+}
+
+func TestLoadSeedPrecedence(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		args   []string
+		want   string
+	}{
+		{"positional seed beats the file", `seed = "#ff0000"`, []string{"0,0,255"}, "0,0,255"},
+		{"-rgb beats the file", `seed = "#ff0000"`, []string{"-rgb", "0,0,255"}, "0,0,255"},
+		{"-rgb beats a positional seed", ``, []string{"-rgb", "0,0,255", "1,2,3"}, "0,0,255"},
+		{"file seed without a command line seed", `seed = "#ff0000"`, nil, "#ff0000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := loadTestOptions(t, tt.config, tt.args...).seed; got != tt.want {
+				t.Errorf("seed = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadSeedSourcePrecedence(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       string
+		args         []string
+		seed, source string
+	}{
+		{"-rgb clears the file's seed_from", `seed_from = "hyprpaper"`, []string{"-rgb", "28,32,39"}, "28,32,39", ""},
+		{"positional seed clears the file's seed_from", `seed_from = "hyprpaper"`, []string{"28,32,39"}, "28,32,39", ""},
+		{"-seed-from clears the file's seed", `seed = "#ff0000"`, []string{"-seed-from", "accent"}, "", "accent"},
+		{"file seed_from without a command line seed", `seed_from = "hyprpaper"`, nil, "", "hyprpaper"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := loadTestOptions(t, tt.config, tt.args...)
+			if opts.seed != tt.seed || opts.seedFrom != tt.source {
+				t.Errorf("seed, seedFrom = %q, %q, want %q, %q", opts.seed, opts.seedFrom, tt.seed, tt.source)
+			}
+		})
+	}
+
+	// spec must not try to read hyprpaper.conf.
+	opts := loadTestOptions(t, `seed_from = "hyprpaper"`, "-rgb", "28,32,39")
+	if _, err := opts.spec(); err != nil {
+		t.Errorf("spec: %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"material-gtk/pkg/m3color"
+)
+
+// customColors collects repeated -custom NAME=COLOR[:harmonize] flags.
+type customColors []m3color.CustomColor
+
+func (c *customColors) String() string {
+	if c == nil {
+		return ""
+	}
+	specs := make([]string, len(*c))
+	for i, cc := range *c {
+		specs[i] = cc.Name + "=" + colorToHex(cc.Value)
+		if cc.Harmonize {
+			specs[i] += ":harmonize"
+		}
+	}
+	return strings.Join(specs, " ")
+}
+
+func (c *customColors) Set(spec string) error {
+	name, value, ok := strings.Cut(spec, "=")
+	if !ok {
+		return fmt.Errorf("expected NAME=COLOR[:harmonize], got %q", spec)
+	}
+	value, harmonize := strings.CutSuffix(value, ":harmonize")
+	cc, err := newCustomColor(name, value, harmonize)
+	if err != nil {
+		return err
+	}
+	*c = append(*c, cc)
+	return nil
+}
+
+func newCustomColor(name, value string, harmonize bool) (m3color.CustomColor, error) {
+	v, err := parseSeed(value)
+	if err != nil {
+		return m3color.CustomColor{}, fmt.Errorf("custom color %q: %w", name, err)
+	}
+	return m3color.NewCustomColor(strings.TrimSpace(name), v, harmonize)
+}
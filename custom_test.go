@@ -0,0 +1,83 @@
+package main
+
+import (
+	"image/color"
+	"reflect"
+	"strings"
+	"testing"
+
+	"material-gtk/pkg/m3color"
+)
+
+func TestCustomColorsSet(t *testing.T) {
+	tests := []struct {
+		spec string
+		want m3color.CustomColor
+		err  string
+	}{
+		{"link=#1a73e8", m3color.CustomColor{Name: "link", Value: color.RGBA{0x1a, 0x73, 0xe8, 255}}, ""},
+		{"diff-added=#2da44e:harmonize", m3color.CustomColor{Name: "diff-added", Value: color.RGBA{0x2d, 0xa4, 0x4e, 255}, Harmonize: true}, ""},
+		{"brand_2=255,0,0", m3color.CustomColor{Name: "brand_2", Value: color.RGBA{255, 0, 0, 255}}, ""},
+		{" link =#1a73e8", m3color.CustomColor{Name: "link", Value: color.RGBA{0x1a, 0x73, 0xe8, 255}}, ""},
+		{"link", m3color.CustomColor{}, "expected NAME=COLOR"},
+		{"link=blue", m3color.CustomColor{}, `custom color "link"`},
+		{"link=#1a73e8:harmonise", m3color.CustomColor{}, `custom color "link"`},
+		{"-=#1a73e8", m3color.CustomColor{}, "invalid custom color name"},
+		{"=#1a73e8", m3color.CustomColor{}, "invalid custom color name"},
+		{"Link=#1a73e8", m3color.CustomColor{}, "invalid custom color name"},
+	}
+	for _, tt := range tests {
+		var c customColors
+		err := c.Set(tt.spec)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Set(%q) = %v, want an error containing %q", tt.spec, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || len(c) != 1 || c[0] != tt.want {
+			t.Errorf("Set(%q) = %v, %v; want %v", tt.spec, c, err, tt.want)
+		}
+	}
+}
+
+func TestCustomFlags(t *testing.T) {
+	opts := loadTestOptions(t, "[[custom_colors]]\nname = \"file\"\ncolor = \"#000000\"\n",
+		"-custom", "link=#1a73e8", "-custom", "diff-added=#2da44e:harmonize", "-rgb", "#336699")
+	want := customColors{
+		{Name: "link", Value: color.RGBA{0x1a, 0x73, 0xe8, 255}},
+		{Name: "diff-added", Value: color.RGBA{0x2d, 0xa4, 0x4e, 255}, Harmonize: true},
+	}
+	// Flags replace the file's custom colors.
+	if !reflect.DeepEqual(opts.custom, want) {
+		t.Errorf("custom = %v, want %v", opts.custom, want)
+	}
+	if got := opts.custom.String(); got != "link=#1a73e8 diff-added=#2da44e:harmonize" {
+		t.Errorf("String() = %q", got)
+	}
+
+	spec, err := opts.spec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	css, err := generateGTKTheme(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, role := range []string{"link", "onLinkContainer", "diffAdded", "onDiffAddedContainer"} {
+		if !strings.Contains(css, "@define-color "+role+" #") {
+			t.Errorf("CSS does not define @%s", role)
+		}
+	}
+}
+
+func TestCustomColorClash(t *testing.T) {
+	opts := loadTestOptions(t, "", "-custom", "on-primary=#ff0000", "-rgb", "#336699")
+	spec, err := opts.spec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := generateGTKTheme(spec); err == nil || !strings.Contains(err.Error(), "clashes with the onPrimary role") {
+		t.Errorf("generateGTKTheme = %v, want a clash with onPrimary", err)
+	}
+}
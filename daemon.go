@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"material-gtk/pkg/m3color"
+)
+
+// maxSleep caps how long the daemon sleeps between checks. Go timers do not
+// advance while the machine is suspended, so long sleeps would otherwise
+// overshoot a transition after resume.
+const maxSleep = time.Minute
+
+// schedule decides which mode applies at a given time.
+type schedule interface {
+	// At returns the mode in effect at t and when it next changes.
+	At(t time.Time) (m3color.Mode, time.Time)
+}
+
+// fixedSchedule switches at the same local times every day.
+type fixedSchedule struct {
+	light, dark int // minutes after local midnight
+}
+
+func (s fixedSchedule) At(t time.Time) (m3color.Mode, time.Time) {
+	// The mode is set by the latest transition at or before t, looking back
+	// as far as yesterday, and changes at the first transition after t.
+	mode, latest := m3color.Dark, time.Time{}
+	var next time.Time
+	for day := -1; day <= 1; day++ {
+		for _, tr := range []struct {
+			minutes int
+			mode    m3color.Mode
+		}{{s.light, m3color.Light}, {s.dark, m3color.Dark}} {
+			// time.Date rather than Add keeps the wall clock time across DST
+			at := time.Date(t.Year(), t.Month(), t.Day()+day, 0, tr.minutes, 0, 0, t.Location())
+			switch {
+			case !at.After(t) && at.After(latest):
+				latest, mode = at, tr.mode
+			case at.After(t) && (next.IsZero() || at.Before(next)):
+				next = at
+			}
+		}
+	}
+	return mode, next
+}
+
+// sunSchedule is light between sunrise and sunset at a location.
+type sunSchedule struct {
+	latitude, longitude float64
+}
+
+func (s sunSchedule) At(t time.Time) (m3color.Mode, time.Time) {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	tomorrow := midnight.AddDate(0, 0, 1)
+
+	sunrise, sunset, polarDay, ok := sunTimes(t, s.latitude, s.longitude)
+	if !ok {
+		// Midnight sun or polar night: re-evaluate tomorrow.
+		if polarDay {
+			return m3color.Light, tomorrow
+		}
+		return m3color.Dark, tomorrow
+	}
+
+	switch {
+	case t.Before(sunrise):
+		return m3color.Dark, sunrise
+	case t.Before(sunset):
+		return m3color.Light, sunset
+	}
+	if rise, _, _, ok := sunTimes(tomorrow, s.latitude, s.longitude); ok {
+		return m3color.Dark, rise
+	}
+	return m3color.Dark, tomorrow
+}
+
+// parseClock parses a local time of day such as "07:30" into minutes after
+// midnight.
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, use HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// runDaemon implements the daemon subcommand: it keeps the theme in the mode
+// the schedule asks for, re-applying whenever it switches. With -mode system
+// it follows the desktop's color-scheme preference instead.
+func runDaemon(args []string) error {
+	fs := flag.NewFlagSet("daemon", flag.ExitOnError)
+	var opts options
+	opts.register(fs)
+	var (
+		lightAt   string
+		darkAt    string
+		latitude  float64
+		longitude float64
+	)
+	fs.StringVar(&lightAt, "light-at", "07:00", "Local time to switch to light mode (HH:MM)")
+	fs.StringVar(&darkAt, "dark-at", "19:00", "Local time to switch to dark mode (HH:MM)")
+	fs.Float64Var(&latitude, "latitude", math.NaN(), "Latitude for sunrise/sunset switching (overrides -light-at/-dark-at)")
+	fs.Float64Var(&longitude, "longitude", math.NaN(), "Longitude for sunrise/sunset switching, positive east")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s daemon [options] [R,G,B]\n\nOptions:\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	cfg, err := opts.loadWithSeedArg(fs)
+	if err != nil {
+		return err
+	}
+	if opts.seed == "" && opts.seedFrom == "" {
+		return fmt.Errorf("daemon needs a seed (R,G,B, -rgb or -seed-from)")
+	}
+	opts.apply = true
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if opts.mode == modeSystem {
+		return followColorScheme(ctx, &opts)
+	}
+
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if !set["light-at"] && cfg.Schedule.LightAt != "" {
+		lightAt = cfg.Schedule.LightAt
+	}
+	if !set["dark-at"] && cfg.Schedule.DarkAt != "" {
+		darkAt = cfg.Schedule.DarkAt
+	}
+	if !set["latitude"] && cfg.Schedule.Latitude != nil {
+		latitude = *cfg.Schedule.Latitude
+	}
+	if !set["longitude"] && cfg.Schedule.Longitude != nil {
+		longitude = *cfg.Schedule.Longitude
+	}
+
+	var sched schedule
+	switch {
+	case !math.IsNaN(latitude) || !math.IsNaN(longitude):
+		if math.IsNaN(latitude) || math.IsNaN(longitude) {
+			return fmt.Errorf("-latitude and -longitude must be given together")
+		}
+		if math.Abs(latitude) > 90 || math.Abs(longitude) > 180 {
+			return fmt.Errorf("invalid location %g,%g", latitude, longitude)
+		}
+		sched = sunSchedule{latitude: latitude, longitude: longitude}
+	default:
+		light, err := parseClock(lightAt)
+		if err != nil {
+			return err
+		}
+		dark, err := parseClock(darkAt)
+		if err != nil {
+			return err
+		}
+		if light == dark {
+			return fmt.Errorf("-light-at and -dark-at must differ")
+		}
+		sched = fixedSchedule{light: light, dark: dark}
+	}
+
+	return runSchedule(ctx, &opts, sched)
+}
+
+// runSchedule applies the scheduled mode now and at every transition.
+func runSchedule(ctx context.Context, opts *options, sched schedule) error {
+	current, applied := m3color.Light, false
+	for {
+		mode, next := sched.At(time.Now())
+		if !applied || mode != current {
+			fmt.Printf("🌓 Switching to %s mode (next change %s)\n", mode, next.Format("Mon 15:04"))
+			o := *opts
+			o.mode = mode.String()
+			if err := generate(&o); err != nil {
+				log.Printf("Warning: %v", err)
+			} else {
+				current, applied = mode, true
+			}
+		}
+
+		wait := min(time.Until(next), maxSleep)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(max(wait, time.Second)):
+		}
+	}
+}
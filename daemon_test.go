@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"material-gtk/pkg/m3color"
+)
+
+func TestFixedSchedule(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := func(loc *time.Location, y int, m time.Month, d, h, min int) time.Time {
+		return time.Date(y, m, d, h, min, 0, 0, loc)
+	}
+	dayTime := fixedSchedule{light: 7 * 60, dark: 19 * 60}
+	nightShift := fixedSchedule{light: 20 * 60, dark: 8 * 60}
+
+	tests := []struct {
+		name     string
+		schedule fixedSchedule
+		t        time.Time
+		mode     m3color.Mode
+		next     time.Time
+	}{
+		{"before light", dayTime, day(time.UTC, 2024, 5, 10, 6, 59), m3color.Dark, day(time.UTC, 2024, 5, 10, 7, 0)},
+		{"exactly at light", dayTime, day(time.UTC, 2024, 5, 10, 7, 0), m3color.Light, day(time.UTC, 2024, 5, 10, 19, 0)},
+		{"midday", dayTime, day(time.UTC, 2024, 5, 10, 12, 0), m3color.Light, day(time.UTC, 2024, 5, 10, 19, 0)},
+		{"exactly at dark", dayTime, day(time.UTC, 2024, 5, 10, 19, 0), m3color.Dark, day(time.UTC, 2024, 5, 11, 7, 0)},
+		{"after midnight", dayTime, day(time.UTC, 2024, 5, 10, 0, 30), m3color.Dark, day(time.UTC, 2024, 5, 10, 7, 0)},
+
+		{"dark_at before light_at, morning", nightShift, day(time.UTC, 2024, 5, 10, 3, 0), m3color.Light, day(time.UTC, 2024, 5, 10, 8, 0)},
+		{"dark_at before light_at, midday", nightShift, day(time.UTC, 2024, 5, 10, 12, 0), m3color.Dark, day(time.UTC, 2024, 5, 10, 20, 0)},
+		{"dark_at before light_at, evening", nightShift, day(time.UTC, 2024, 5, 10, 22, 0), m3color.Light, day(time.UTC, 2024, 5, 11, 8, 0)},
+
+		{"spring forward night", dayTime, day(berlin, 2024, 3, 31, 1, 30), m3color.Dark, day(berlin, 2024, 3, 31, 7, 0)},
+		{"evening before spring forward", dayTime, day(berlin, 2024, 3, 30, 20, 0), m3color.Dark, day(berlin, 2024, 3, 31, 7, 0)},
+		{"spring forward day", dayTime, day(berlin, 2024, 3, 31, 12, 0), m3color.Light, day(berlin, 2024, 3, 31, 19, 0)},
+		{"evening before fall back", dayTime, day(berlin, 2024, 10, 26, 20, 0), m3color.Dark, day(berlin, 2024, 10, 27, 7, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mode, next := tt.schedule.At(tt.t)
+			if mode != tt.mode || !next.Equal(tt.next) {
+				t.Errorf("At(%v) = %v, %v; want %v, %v", tt.t, mode, next, tt.mode, tt.next)
+			}
+		})
+	}
+}
+
+func TestSunSchedulePolar(t *testing.T) {
+	longyearbyen := sunSchedule{latitude: 78.22, longitude: 15.65}
+	tests := []struct {
+		t    time.Time
+		mode m3color.Mode
+	}{
+		{time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC), m3color.Light},
+		{time.Date(2024, 12, 21, 12, 0, 0, 0, time.UTC), m3color.Dark},
+	}
+	for _, tt := range tests {
+		mode, next := longyearbyen.At(tt.t)
+		tomorrow := time.Date(tt.t.Year(), tt.t.Month(), tt.t.Day()+1, 0, 0, 0, 0, time.UTC)
+		if mode != tt.mode || !next.Equal(tomorrow) {
+			t.Errorf("At(%v) = %v, %v; want %v, %v", tt.t, mode, next, tt.mode, tomorrow)
+		}
+	}
+}
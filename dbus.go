@@ -0,0 +1,249 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	dconfBusName     = "ca.desrt.dconf"
+	dconfWriterPath  = "/ca/desrt/dconf/Writer/user"
+	dconfWriterIface = "ca.desrt.dconf.Writer"
+
+	portalBusName       = "org.freedesktop.portal.Desktop"
+	portalPath          = "/org/freedesktop/portal/desktop"
+	portalSettingsIface = "org.freedesktop.portal.Settings"
+)
+
+// settingConfirmTimeout bounds how long we wait for dconf to commit a change
+// before giving up. portalRelayTimeout bounds the extra wait for the settings
+// portal to relay a committed change: portal backends that do not expose the
+// GNOME namespaces never relay it, and neither does any portal when the value
+// did not actually change. They are variables so tests can shorten them.
+var (
+	settingConfirmTimeout = 5 * time.Second
+	portalRelayTimeout    = 1 * time.Second
+)
+
+// sessionBus opens a private connection to the session bus. Tests point it
+// at a bus daemon of their own.
+var sessionBus = func() (*dbus.Conn, error) { return dbus.ConnectSessionBus() }
+
+// errBusUnavailable is returned when the session bus or the dconf writer
+// cannot be reached, in which case callers fall back to the command line
+// tools.
+var errBusUnavailable = errors.New("D-Bus settings service unavailable")
+
+// dconfClient writes dconf keys over the session bus and waits for the
+// resulting change notifications.
+type dconfClient struct {
+	conn    *dbus.Conn
+	signals chan *dbus.Signal
+	portal  bool // the settings portal is running and relays changes
+}
+
+// connectDconf connects to the session bus and subscribes to the change
+// signals of the dconf writer and the settings portal.
+func connectDconf() (*dconfClient, error) {
+	conn, err := sessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errBusUnavailable, err)
+	}
+
+	if !nameAvailable(conn, dconfBusName) {
+		conn.Close()
+		return nil, fmt.Errorf("%w: %s is not on the bus", errBusUnavailable, dconfBusName)
+	}
+
+	if err := conn.AddMatchSignal(
+		dbus.WithMatchObjectPath(dconfWriterPath),
+		dbus.WithMatchInterface(dconfWriterIface),
+		dbus.WithMatchMember("Notify"),
+	); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("%w: %v", errBusUnavailable, err)
+	}
+
+	c := &dconfClient{conn: conn, signals: make(chan *dbus.Signal, 16)}
+	if nameHasOwner(conn, portalBusName) {
+		if err := conn.AddMatchSignal(
+			dbus.WithMatchObjectPath(portalPath),
+			dbus.WithMatchInterface(portalSettingsIface),
+			dbus.WithMatchMember("SettingChanged"),
+		); err == nil {
+			c.portal = true
+		}
+	}
+	conn.Signal(c.signals)
+	return c, nil
+}
+
+func (c *dconfClient) Close() error {
+	c.conn.RemoveSignal(c.signals)
+	return c.conn.Close()
+}
+
+// SetString writes a string value to a dconf key such as
+// /org/gnome/desktop/interface/gtk-theme and blocks until dconf has
+// committed it. When the settings portal is running, it then waits up to
+// portalRelayTimeout for the portal to relay the new value to applications;
+// a missing relay is not an error.
+func (c *dconfClient) SetString(key, value string) error {
+	var tag string
+	blob := encodeDconfChangeset(map[string]string{key: value})
+	obj := c.conn.Object(dconfBusName, dconfWriterPath)
+	if err := obj.Call(dconfWriterIface+".Change", 0, blob).Store(&tag); err != nil {
+		return fmt.Errorf("dconf change of %s failed: %w", key, err)
+	}
+
+	namespace, name := splitDconfKey(key)
+	committed, relayed := false, !c.portal
+	timeout := time.After(settingConfirmTimeout)
+	var relayTimeout <-chan time.Time // started once dconf has committed
+	for !committed || !relayed {
+		select {
+		case sig, ok := <-c.signals:
+			if !ok {
+				return fmt.Errorf("session bus connection closed while waiting for %s", key)
+			}
+			switch sig.Name {
+			case dconfWriterIface + ".Notify":
+				// Notify(s prefix, as changes, s tag)
+				if len(sig.Body) == 3 && sig.Body[2] == tag {
+					committed = true
+					relayTimeout = time.After(portalRelayTimeout)
+				}
+			case portalSettingsIface + ".SettingChanged":
+				// SettingChanged(s namespace, s key, v value)
+				if len(sig.Body) == 3 && sig.Body[0] == namespace && sig.Body[1] == name {
+					if v, ok := sig.Body[2].(dbus.Variant); ok && v.Value() == value {
+						relayed = true
+					}
+				}
+			}
+		case <-relayTimeout:
+			return nil
+		case <-timeout:
+			return fmt.Errorf("timed out waiting for dconf to commit %s=%q", key, value)
+		}
+	}
+	return nil
+}
+
+// dconfToggle switches key to reload and back to theme over D-Bus, using the
+// change signals instead of a fixed delay to know when each switch landed.
+// The switch back is attempted even if the first switch failed, so the
+// desktop is not left on reload.
+func dconfToggle(key, theme, reload string) error {
+	c, err := connectDconf()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	var errs []error
+	if err := c.SetString(key, reload); err != nil {
+		errs = append(errs, fmt.Errorf("failed to switch to %s: %w", reload, err))
+	}
+	if err := c.SetString(key, theme); err != nil {
+		errs = append(errs, fmt.Errorf("failed to switch back to %s: %w", theme, err))
+	}
+	return errors.Join(errs...)
+}
+
+// splitDconfKey splits a dconf path into the GSettings style namespace and key
+// used by the settings portal.
+func splitDconfKey(key string) (string, string) {
+	i := strings.LastIndexByte(key, '/')
+	return strings.ReplaceAll(strings.Trim(key[:i], "/"), "/", "."), key[i+1:]
+}
+
+func nameHasOwner(conn *dbus.Conn, name string) bool {
+	var has bool
+	err := conn.BusObject().Call("org.freedesktop.DBus.NameHasOwner", 0, name).Store(&has)
+	return err == nil && has
+}
+
+// nameAvailable reports whether name is owned or can be bus-activated.
+func nameAvailable(conn *dbus.Conn, name string) bool {
+	if nameHasOwner(conn, name) {
+		return true
+	}
+	var names []string
+	if err := conn.BusObject().Call("org.freedesktop.DBus.ListActivatableNames", 0).Store(&names); err != nil {
+		return false
+	}
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeDconfChangeset serialises string writes in the GVariant a{smv} format
+// the dconf writer expects (little endian, as produced by dconf itself).
+func encodeDconfChangeset(changes map[string]string) []byte {
+	keys := make([]string, 0, len(changes))
+	for k := range changes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var body []byte
+	var ends []int
+	for _, k := range keys {
+		body = padTo(body, 8) // dict entries are 8-aligned because of v
+		body = append(body, encodeDictEntry(k, changes[k])...)
+		ends = append(ends, len(body))
+	}
+	return appendFramingOffsets(body, ends)
+}
+
+// encodeDictEntry serialises {s mv} holding Just(<'value'>).
+func encodeDictEntry(key, value string) []byte {
+	var entry []byte
+	entry = append(entry, key...)
+	entry = append(entry, 0)
+	keyEnd := len(entry)
+
+	entry = padTo(entry, 8)
+	entry = append(entry, value...)
+	entry = append(entry, 0)   // end of string
+	entry = append(entry, 0)   // variant separator
+	entry = append(entry, 's') // variant type
+	entry = append(entry, 0)   // maybe: Just marker for non-fixed types
+
+	// Only the key's end needs a framing offset; the last member's end is
+	// implied by the container size.
+	return appendFramingOffsets(entry, []int{keyEnd})
+}
+
+// appendFramingOffsets appends GVariant framing offsets, choosing the smallest
+// offset size that can address the whole container.
+func appendFramingOffsets(body []byte, ends []int) []byte {
+	size := 1
+	for size < 8 && len(body)+len(ends)*size > 1<<(8*size)-1 {
+		size *= 2
+	}
+
+	var buf [8]byte
+	for _, end := range ends {
+		binary.LittleEndian.PutUint64(buf[:], uint64(end))
+		body = append(body, buf[:size]...)
+	}
+	return body
+}
+
+func padTo(b []byte, align int) []byte {
+	for len(b)%align != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"os/exec"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// startTestBus starts a private dbus-daemon for the test and points
+// sessionBus at it. The test is skipped if dbus-daemon is not installed.
+func startTestBus(t *testing.T) string {
+	t.Helper()
+	daemon, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon not installed")
+	}
+
+	cmd := exec.Command(daemon, "--session", "--nofork", "--print-address")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start dbus-daemon: %v", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatalf("failed to read the bus address: %v", err)
+	}
+	addr := strings.TrimSpace(line)
+
+	prev := sessionBus
+	sessionBus = func() (*dbus.Conn, error) { return dbus.Connect(addr) }
+	t.Cleanup(func() { sessionBus = prev })
+	return addr
+}
+
+// testBusConn connects to the test bus and requests names on it.
+func testBusConn(t *testing.T, addr string, names ...string) *dbus.Conn {
+	t.Helper()
+	conn, err := dbus.Connect(addr)
+	if err != nil {
+		t.Fatalf("failed to connect to the test bus: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	for _, name := range names {
+		reply, err := conn.RequestName(name, dbus.NameFlagDoNotQueue)
+		if err != nil || reply != dbus.RequestNameReplyPrimaryOwner {
+			t.Fatalf("failed to own %s: %v", name, err)
+		}
+	}
+	return conn
+}
+
+// fakeDconf is a dconf writer that records the values written and, as
+// configured, confirms them with Notify and relays them the way the settings
+// portal does.
+type fakeDconf struct {
+	conn       *dbus.Conn
+	notify     bool
+	relay      bool
+	relayDelay time.Duration
+
+	mu     sync.Mutex
+	keys   []string
+	values []string
+}
+
+func (f *fakeDconf) Change(blob []byte) (string, *dbus.Error) {
+	key, value := decodeTestChangeset(blob)
+	f.mu.Lock()
+	f.keys = append(f.keys, key)
+	f.values = append(f.values, value)
+	tag := strconv.Itoa(len(f.values))
+	f.mu.Unlock()
+
+	go func() {
+		if f.notify {
+			f.conn.Emit(dconfWriterPath, dconfWriterIface+".Notify", key, []string{""}, tag)
+		}
+		if f.relay {
+			time.Sleep(f.relayDelay)
+			namespace, name := splitDconfKey(key)
+			f.conn.Emit(portalPath, portalSettingsIface+".SettingChanged", namespace, name, dbus.MakeVariant(value))
+		}
+	}()
+	return tag, nil
+}
+
+// writtenKeys returns the keys written, in order.
+func (f *fakeDconf) writtenKeys() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.keys...)
+}
+
+func (f *fakeDconf) written() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.values...)
+}
+
+// decodeTestChangeset reads back the single string write of an a{smv}
+// changeset: the key up to its NUL, then the value at the next multiple of 8.
+func decodeTestChangeset(blob []byte) (key, value string) {
+	keyEnd := bytes.IndexByte(blob, 0)
+	start := (keyEnd + 1 + 7) &^ 7
+	valueEnd := start + bytes.IndexByte(blob[start:], 0)
+	return string(blob[:keyEnd]), string(blob[start:valueEnd])
+}
+
+// startFakeDconf exports f as the dconf writer on the test bus, also owning
+// the portal's name when portal is set.
+func startFakeDconf(t *testing.T, addr string, f *fakeDconf, portal bool) {
+	t.Helper()
+	names := []string{dconfBusName}
+	if portal {
+		names = append(names, portalBusName)
+	}
+	f.conn = testBusConn(t, addr, names...)
+	if err := f.conn.Export(f, dconfWriterPath, dconfWriterIface); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// setTimeouts shortens the confirmation timeouts for the test.
+func setTimeouts(t *testing.T, confirm, relay time.Duration) {
+	prevConfirm, prevRelay := settingConfirmTimeout, portalRelayTimeout
+	settingConfirmTimeout, portalRelayTimeout = confirm, relay
+	t.Cleanup(func() { settingConfirmTimeout, portalRelayTimeout = prevConfirm, prevRelay })
+}
+
+func TestEncodeDconfChangeset(t *testing.T) {
+	// Serialised by GLib's g_variant_parse with type a{smv}.
+	tests := []struct {
+		changes map[string]string
+		want    string
+	}{
+		{
+			map[string]string{"/org/gnome/desktop/interface/gtk-theme": "OmarchyThemeTemp"},
+			"/org/gnome/desktop/interface/gtk-theme\x00\x00OmarchyThemeTemp\x00\x00s\x00\x27\x3d",
+		},
+		{
+			map[string]string{"/a/b": "x", "/a/c": "yz"},
+			"/a/b\x00\x00\x00\x00x\x00\x00s\x00\x05\x00\x00/a/c\x00\x00\x00\x00yz\x00\x00s\x00\x05\x0e\x1f",
+		},
+	}
+	for _, tt := range tests {
+		if got := string(encodeDconfChangeset(tt.changes)); got != tt.want {
+			t.Errorf("encodeDconfChangeset(%v) =\n%q\nwant\n%q", tt.changes, got, tt.want)
+		}
+	}
+}
+
+func TestDconfToggle(t *testing.T) {
+	const key = "/org/gnome/desktop/interface/gtk-theme"
+
+	t.Run("waits for dconf and the portal", func(t *testing.T) {
+		addr := startTestBus(t)
+		setTimeouts(t, 5*time.Second, 5*time.Second)
+		f := &fakeDconf{notify: true, relay: true, relayDelay: 200 * time.Millisecond}
+		startFakeDconf(t, addr, f, true)
+
+		start := time.Now()
+		if err := dconfToggle(key, "Theme", "ThemeTemp"); err != nil {
+			t.Fatalf("dconfToggle: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed < 2*f.relayDelay {
+			t.Errorf("returned after %v, before both relays (%v each)", elapsed, f.relayDelay)
+		}
+		if got, want := f.written(), []string{"ThemeTemp", "Theme"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("wrote %q, want %q", got, want)
+		}
+	})
+
+	t.Run("portal that never relays", func(t *testing.T) {
+		addr := startTestBus(t)
+		setTimeouts(t, 5*time.Second, 100*time.Millisecond)
+		f := &fakeDconf{notify: true}
+		startFakeDconf(t, addr, f, true)
+
+		start := time.Now()
+		if err := dconfToggle(key, "Theme", "ThemeTemp"); err != nil {
+			t.Fatalf("dconfToggle: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("took %v, want about two relay timeouts", elapsed)
+		}
+		if got, want := f.written(), []string{"ThemeTemp", "Theme"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("wrote %q, want %q", got, want)
+		}
+	})
+
+	t.Run("dconf never commits", func(t *testing.T) {
+		addr := startTestBus(t)
+		setTimeouts(t, 100*time.Millisecond, 100*time.Millisecond)
+		f := &fakeDconf{}
+		startFakeDconf(t, addr, f, false)
+
+		err := dconfToggle(key, "Theme", "ThemeTemp")
+		if err == nil || !strings.Contains(err.Error(), "timed out") {
+			t.Fatalf("dconfToggle = %v, want a timeout", err)
+		}
+		// The switch back is still attempted.
+		if got, want := f.written(), []string{"ThemeTemp", "Theme"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("wrote %q, want %q", got, want)
+		}
+	})
+
+	t.Run("no dconf writer", func(t *testing.T) {
+		startTestBus(t)
+		if err := dconfToggle(key, "Theme", "ThemeTemp"); !errors.Is(err, errBusUnavailable) {
+			t.Errorf("dconfToggle = %v, want errBusUnavailable", err)
+		}
+	})
+}
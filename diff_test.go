@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// numbered returns "line from" to "line to", one per line, with the lines in
+// replace swapped for their values.
+func numbered(from, to int, replace map[int]string) string {
+	var sb strings.Builder
+	for i := from; i <= to; i++ {
+		if r, ok := replace[i]; ok {
+			sb.WriteString(r + "\n")
+			continue
+		}
+		fmt.Fprintf(&sb, "line %d\n", i)
+	}
+	return sb.String()
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	// Each want is the output of diff -u --label old --label new.
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{
+			name: "identical",
+			a:    numbered(1, 5, nil),
+			b:    numbered(1, 5, nil),
+			want: "",
+		},
+		{
+			name: "insertion at the start",
+			a:    numbered(1, 10, nil),
+			b:    "new\n" + numbered(1, 10, nil),
+			want: `--- old
++++ new
+@@ -1,3 +1,4 @@
++new
+ line 1
+ line 2
+ line 3
+`,
+		},
+		{
+			name: "deletion at the end",
+			a:    numbered(1, 10, nil),
+			b:    numbered(1, 9, nil),
+			want: `--- old
++++ new
+@@ -7,4 +7,3 @@
+ line 7
+ line 8
+ line 9
+-line 10
+`,
+		},
+		{
+			name: "two changes merged into one hunk",
+			a:    numbered(1, 12, nil),
+			b:    numbered(1, 12, map[int]string{3: "LINE 3", 9: "LINE 9"}),
+			want: `--- old
++++ new
+@@ -1,12 +1,12 @@
+ line 1
+ line 2
+-line 3
++LINE 3
+ line 4
+ line 5
+ line 6
+ line 7
+ line 8
+-line 9
++LINE 9
+ line 10
+ line 11
+ line 12
+`,
+		},
+		{
+			name: "two changes in separate hunks",
+			a:    numbered(1, 20, nil),
+			b:    numbered(1, 20, map[int]string{2: "LINE 2", 15: "LINE 15"}),
+			want: `--- old
++++ new
+@@ -1,5 +1,5 @@
+ line 1
+-line 2
++LINE 2
+ line 3
+ line 4
+ line 5
+@@ -12,7 +12,7 @@
+ line 12
+ line 13
+ line 14
+-line 15
++LINE 15
+ line 16
+ line 17
+ line 18
+`,
+		},
+		{
+			name: "file created from empty",
+			a:    "",
+			b:    "x\ny\n",
+			want: `--- old
++++ new
+@@ -0,0 +1,2 @@
++x
++y
+`,
+		},
+		{
+			name: "newline removed at the end",
+			a:    "a\nb\n",
+			b:    "a\nb",
+			want: `--- old
++++ new
+@@ -1,2 +1,2 @@
+ a
+-b
++b
+\ No newline at end of file
+`,
+		},
+		{
+			name: "newline added at the end",
+			a:    "a\nb",
+			b:    "a\nb\n",
+			want: `--- old
++++ new
+@@ -1,2 +1,2 @@
+ a
+-b
+\ No newline at end of file
++b
+`,
+		},
+		{
+			name: "file without a newline created from empty",
+			a:    "",
+			b:    "a\nb",
+			want: `--- old
++++ new
+@@ -0,0 +1,2 @@
++a
++b
+\ No newline at end of file
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unifiedDiff("old", "new", tt.a, tt.b); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// plannedWrite is a file a run would create or replace.
+type plannedWrite struct {
+	Path    string
+	Content []byte
+}
+
+// themeWrites returns the absolute writes installTheme would perform.
+func themeWrites(themesDir, name string, files []themeFile) []plannedWrite {
+	writes := make([]plannedWrite, len(files))
+	for i, f := range files {
+		writes[i] = plannedWrite{
+			Path:    filepath.Join(themesDir, name, f.Path),
+			Content: f.Content,
+		}
+	}
+	return writes
+}
+
+// printDryRun reports the files that would be written, how they differ from
+// what is on disk, and the commands that would be run to apply the theme.
+func printDryRun(w io.Writer, writes []plannedWrite, commands []string) error {
+	if len(writes) == 0 {
+		fmt.Fprintln(w, "No files would be written.")
+	} else {
+		fmt.Fprintln(w, "Files that would be written:")
+	}
+
+	var diffs []string
+	for _, pw := range writes {
+		existing, err := os.ReadFile(pw.Path)
+		switch {
+		case os.IsNotExist(err):
+			fmt.Fprintf(w, "  %s (new)\n", pw.Path)
+		case err != nil:
+			return fmt.Errorf("failed to read %s: %w", pw.Path, err)
+		case string(existing) == string(pw.Content):
+			fmt.Fprintf(w, "  %s (unchanged)\n", pw.Path)
+		case bytes.IndexByte(pw.Content, 0) >= 0:
+			// Binary files such as the PNG preview are not diffed.
+			fmt.Fprintf(w, "  %s (changed)\n", pw.Path)
+		default:
+			fmt.Fprintf(w, "  %s (changed)\n", pw.Path)
+			diffs = append(diffs, unifiedDiff(pw.Path, pw.Path+" (new)", string(existing), string(pw.Content)))
+		}
+	}
+
+	for _, d := range diffs {
+		fmt.Fprintln(w)
+		fmt.Fprint(w, d)
+	}
+
+	if len(commands) > 0 {
+		fmt.Fprintln(w, "\nCommands that would run:")
+		for _, c := range commands {
+			fmt.Fprintf(w, "  %s\n", c)
+		}
+	}
+	return nil
+}
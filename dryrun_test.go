@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPrintDryRun(t *testing.T) {
+	dir := t.TempDir()
+	unchanged := filepath.Join(dir, "unchanged.css")
+	changed := filepath.Join(dir, "changed.css")
+	binary := filepath.Join(dir, "preview.png")
+	created := filepath.Join(dir, "new.css")
+	for path, content := range map[string]string{
+		unchanged: "a\n",
+		changed:   "a\nb\n",
+		binary:    "\x89PNG\x00old",
+	} {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out strings.Builder
+	err := printDryRun(&out, []plannedWrite{
+		{Path: unchanged, Content: []byte("a\n")},
+		{Path: changed, Content: []byte("a\nc\n")},
+		{Path: binary, Content: []byte("\x89PNG\x00new")},
+		{Path: created, Content: []byte("x\n")},
+	}, []string{"gsettings set org.gnome.desktop.interface gtk-theme OmarchyThemeTemp"})
+	if err != nil {
+		t.Fatalf("printDryRun: %v", err)
+	}
+
+	want := "Files that would be written:\n" +
+		"  " + unchanged + " (unchanged)\n" +
+		"  " + changed + " (changed)\n" +
+		"  " + binary + " (changed)\n" +
+		"  " + created + " (new)\n" +
+		"\n" +
+		"--- " + changed + "\n" +
+		"+++ " + changed + " (new)\n" +
+		"@@ -1,2 +1,2 @@\n" +
+		" a\n" +
+		"-b\n" +
+		"+c\n" +
+		"\nCommands that would run:\n" +
+		"  gsettings set org.gnome.desktop.interface gtk-theme OmarchyThemeTemp\n"
+	if got := out.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestPrintDryRunNothing(t *testing.T) {
+	var out strings.Builder
+	if err := printDryRun(&out, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), "No files would be written.\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
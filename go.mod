@@ -2,4 +2,11 @@ module material-gtk
 
 go 1.24.6
 
-require github.com/gio-eui/md3-colors v0.0.0-20230728141345-c5bc4138383e // indirect
+require (
+	github.com/BurntSushi/toml v1.6.0
+	github.com/fsnotify/fsnotify v1.9.0
+	github.com/godbus/dbus/v5 v5.2.2
+	golang.org/x/term v0.26.0
+)
+
+require golang.org/x/sys v0.27.0 // indirect
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image/color"
+	"os"
+	"strings"
+
+	"material-gtk/pkg/m3color"
+)
+
+// runAnalogous implements the analogous subcommand: colors of similar warmth
+// around the seed, one #rrggbb per line with the seed in the middle.
+func runAnalogous(args []string) error {
+	fs := flag.NewFlagSet("analogous", flag.ExitOnError)
+	count := fs.Int("count", 5, "Number of colors, including the seed")
+	divisions := fs.Int("divisions", 12, "Number of equal temperature steps around the hue circle")
+	seed, err := harmonySeed(fs, args)
+	if err != nil {
+		return err
+	}
+	if *count < 1 || *divisions < 1 {
+		return fmt.Errorf("-count and -divisions must be positive")
+	}
+
+	for _, c := range m3color.NewTemperatureCache(seed).Analogous(*count, *divisions) {
+		fmt.Println(colorToHex(c))
+	}
+	return nil
+}
+
+// runComplement implements the complement subcommand: the color whose
+// warmth mirrors the seed's.
+func runComplement(args []string) error {
+	fs := flag.NewFlagSet("complement", flag.ExitOnError)
+	seed, err := harmonySeed(fs, args)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(colorToHex(m3color.NewTemperatureCache(seed).Complement()))
+	return nil
+}
+
+// harmonySeed parses the seed options shared by analogous and complement and
+// returns the seed color.
+func harmonySeed(fs *flag.FlagSet, args []string) (color.RGBA, error) {
+	var opts options
+	fs.StringVar(&opts.configPath, "config", "", "Config file (default: $XDG_CONFIG_HOME/material-gtk/config.toml)")
+	fs.StringVar(&opts.seed, "rgb", "", "Seed color as R,G,B (e.g., 28,32,39) or #rrggbb")
+	fs.StringVar(&opts.seedFrom, "seed-from", "", "Derive the seed instead of passing one: "+strings.Join(seedSourceNames, ", "))
+	fs.StringVar(&opts.wallpaper, "wallpaper", "", "Wallpaper image used with -seed-from wallpaper")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s %s [options] [R,G,B]\n\nOptions:\n", os.Args[0], fs.Name())
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if _, err := opts.loadWithSeedArg(fs); err != nil {
+		return color.RGBA{}, err
+	}
+	if opts.seedFrom != "" {
+		return opts.sourceSeed()
+	}
+	if opts.seed == "" {
+		return color.RGBA{}, fmt.Errorf("%s needs a seed (R,G,B, -rgb or -seed-from)", fs.Name())
+	}
+	return parseSeed(opts.seed)
+}
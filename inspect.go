@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+)
+
+// runInspect implements the inspect subcommand: it shows how an installed
+// theme was generated, from the metadata in its directory, and with
+// -regenerate builds it again from the same settings with this version of
+// the tool.
+func runInspect(args []string) error {
+	fs := flag.NewFlagSet("inspect", flag.ExitOnError)
+	var opts options
+	fs.StringVar(&opts.configPath, "config", "", "Config file (default: $XDG_CONFIG_HOME/material-gtk/config.toml)")
+	fs.StringVar(&opts.themesDir, "themes-dir", "", "Directory themes are installed into (default: $XDG_DATA_HOME/themes or ~/.themes)")
+	fs.BoolVar(&opts.system, "system", false, "Look in the system-wide <prefix>/share/themes")
+	fs.StringVar(&opts.prefix, "prefix", "/usr", "Installation prefix used with -system")
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "With -regenerate, show the files that would be written without writing them")
+	asJSON := fs.Bool("json", false, "Print the metadata as JSON")
+	regenerate := fs.Bool("regenerate", false, "Generate the theme and its recorded outputs again from the same settings")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s inspect [options] [THEME]\n\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "THEME is a theme name (default: the configured theme name) or a theme directory.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if _, err := opts.load(fs); err != nil {
+		return err
+	}
+	dir, err := inspectedThemeDir(&opts, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	meta, err := readThemeMetadata(dir)
+	if err != nil {
+		return err
+	}
+
+	if *regenerate {
+		return regenerateTheme(dir, meta, opts.dryRun)
+	}
+	if *asJSON {
+		data, err := json.MarshalIndent(meta, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	printThemeMetadata(dir, meta)
+	return nil
+}
+
+// inspectedThemeDir resolves the theme argument: a path to a theme directory
+// when it contains a separator, otherwise a name in the themes directory.
+func inspectedThemeDir(opts *options, theme string) (string, error) {
+	if theme == "" {
+		theme = opts.themeName
+	}
+	if theme == "" {
+		theme = defaultThemeName
+	}
+	if strings.ContainsRune(theme, filepath.Separator) {
+		return expandHome(theme)
+	}
+	if err := checkThemeName(theme); err != nil {
+		return "", err
+	}
+	themesDir, err := opts.resolveThemesDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate themes directory: %w", err)
+	}
+	return filepath.Join(themesDir, theme), nil
+}
+
+// printThemeMetadata writes the metadata as aligned fields.
+func printThemeMetadata(dir string, meta themeMetadata) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Theme:\t%s\n", dir)
+	fmt.Fprintf(tw, "Tool:\t%s %s\n", meta.Tool, meta.Version)
+	if meta.Generated != "" {
+		fmt.Fprintf(tw, "Generated:\t%s\n", meta.Generated)
+	}
+	if meta.Reproducible {
+		fmt.Fprintf(tw, "Reproducible:\tyes\n")
+	}
+	fmt.Fprintf(tw, "Seed:\t%s\n", meta.Seed)
+	fmt.Fprintf(tw, "Variant:\t%s\n", meta.Variant)
+	fmt.Fprintf(tw, "Mode:\t%s\n", meta.Mode)
+	fmt.Fprintf(tw, "Contrast:\t%g\n", meta.Contrast)
+	for _, c := range meta.CustomColors {
+		harmonize := ""
+		if c.Harmonize {
+			harmonize = ":harmonize"
+		}
+		fmt.Fprintf(tw, "Custom color:\t%s=%s%s\n", c.Name, c.Color, harmonize)
+	}
+	for _, target := range meta.outputTargetList() {
+		fmt.Fprintf(tw, "Output:\t%s → %s\n", target, meta.Outputs[target])
+	}
+	tw.Flush()
+}
+
+// regenerateTheme generates the theme in dir and its recorded outputs again
+// from meta, replacing the files in place.
+func regenerateTheme(dir string, meta themeMetadata, dryRun bool) error {
+	opts, err := meta.options()
+	if err != nil {
+		return err
+	}
+	spec, err := opts.spec()
+	if err != nil {
+		return err
+	}
+	css, err := generateGTKTheme(spec)
+	if err != nil {
+		return err
+	}
+	outputWrites, err := renderOutputs(spec, opts.outputs)
+	if err != nil {
+		return err
+	}
+	themesDir, name := filepath.Split(filepath.Clean(dir))
+	files, err := themeFiles(name, css, spec, opts.outputs)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		writes := append(outputWrites, themeWrites(themesDir, name, files)...)
+		if err := printDryRun(os.Stdout, writes, nil); err != nil {
+			return fmt.Errorf("dry run failed: %w", err)
+		}
+		return nil
+	}
+
+	for _, w := range outputWrites {
+		if err := writeFileAtomic(w.Path, w.Content, 0644); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+		fmt.Printf("✅ Theme written to %s\n", w.Path)
+	}
+	if _, err := installTheme(themesDir, name, files); err != nil {
+		return fmt.Errorf("failed to install theme: %w", err)
+	}
+	if meta.Version != toolVersion() {
+		fmt.Printf("✅ %s regenerated with material-gtk %s (was %s)\n", dir, toolVersion(), meta.Version)
+	} else {
+		fmt.Printf("✅ %s regenerated\n", dir)
+	}
+	return nil
+}
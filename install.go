@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+)
+
+const defaultThemeName = "OmarchyTheme"
+
+// checkThemeName rejects theme names that are not a single directory below
+// the themes directory.
+func checkThemeName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, "/"+string(filepath.Separator)) {
+		return fmt.Errorf("invalid theme name: %q", name)
+	}
+	return nil
+}
+
+// themeFile is a single file of a theme, relative to the theme directory.
+type themeFile struct {
+	Path    string
+	Content []byte
+}
+
+// userThemesDir returns the per-user theme directory. GTK looks in both
+// $XDG_DATA_HOME/themes and the legacy ~/.themes; we prefer the former when
+// XDG_DATA_HOME is set and keep ~/.themes otherwise so existing installs
+// continue to be picked up.
+func userThemesDir() (string, error) {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "themes"), nil
+	}
+
+	home, err := homeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".themes"), nil
+}
+
+// systemThemesDir returns the system-wide theme directory below prefix,
+// e.g. /usr/share/themes.
+func systemThemesDir(prefix string) string {
+	if prefix == "" {
+		prefix = "/usr"
+	}
+	return filepath.Join(prefix, "share", "themes")
+}
+
+// homeDir resolves the user's home directory without relying on $HOME alone,
+// falling back to the passwd database when the variable is unset.
+func homeDir() (string, error) {
+	if home := os.Getenv("HOME"); home != "" {
+		return home, nil
+	}
+
+	u, err := user.Current()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine home directory: %w", err)
+	}
+	if u.HomeDir == "" {
+		return "", fmt.Errorf("cannot determine home directory for user %s", u.Username)
+	}
+	return u.HomeDir, nil
+}
+
+// themeFiles lays out the files that make up an installable GTK theme
+// generated from spec, including the metadata that records how (outputs
+// are the other files written in the same run).
+func themeFiles(name, css string, spec themeSpec, outputs map[string]string) ([]themeFile, error) {
+	seedColor := spec.Seed
+	index := fmt.Sprintf(`[Desktop Entry]
+Type=X-GNOME-Metatheme
+Name=%s
+Comment=Material 3 Theme - RGB(%d,%d,%d)
+Encoding=UTF-8
+
+[X-GNOME-Metatheme]
+GtkTheme=%s
+IconTheme=Adwaita
+CursorTheme=Adwaita
+`, name, seedColor.R, seedColor.G, seedColor.B, name)
+
+	meta, err := newThemeMetadata(spec, outputs)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return []themeFile{
+		{Path: filepath.Join("gtk-3.0", "gtk.css"), Content: []byte(css)},
+		{Path: filepath.Join("gtk-4.0", "gtk.css"), Content: []byte(css)},
+		{Path: "index.theme", Content: []byte(index)},
+		{Path: metadataFile, Content: append(metadata, '\n')},
+	}, nil
+}
+
+// installTheme writes files into themesDir/name. Every file is replaced
+// atomically, so an interrupted install never leaves a truncated gtk.css
+// behind for GTK to pick up.
+func installTheme(themesDir, name string, files []themeFile) (string, error) {
+	themeDir := filepath.Join(themesDir, name)
+	for _, f := range files {
+		path := filepath.Join(themeDir, f.Path)
+		if err := writeFileAtomic(path, f.Content, 0644); err != nil {
+			return "", err
+		}
+	}
+	return themeDir, nil
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it
+// into place once it has been flushed to disk.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file in %s: %w", dir, err)
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName) // no-op once the rename succeeded
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync %s: %w", path, err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to set permissions on %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", path, err)
+	}
+
+	if err := os.Rename(tmpName, path); err != nil {
+		return fmt.Errorf("failed to move %s into place: %w", path, err)
+	}
+
+	// Persist the rename itself; failure here is not fatal for correctness.
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
@@ -6,11 +6,11 @@ import (
 	"image/color"
 	"log"
 	"os"
-	"os/exec"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
+
+	"material-gtk/pkg/m3color"
 )
 
 func parseRGB(rgbStr string) (uint8, uint8, uint8, error) {
@@ -52,68 +52,137 @@ func colorToHex(c color.RGBA) string {
 	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
 }
 
-func generateGTKTheme(seedColor color.RGBA, variant string) string {
+// themeSpec holds everything that determines a generated theme.
+type themeSpec struct {
+	Seed     color.RGBA
+	Variant  string
+	Mode     m3color.Mode
+	Contrast float64
+	Custom   []m3color.CustomColor
+
+	// Reproducible leaves the generation time out of generated files, so
+	// the same spec always gives byte-identical output.
+	Reproducible bool
+}
+
+// generatedTimeFormat is how generated files show when they were made.
+const generatedTimeFormat = "Mon Jan 2 15:04:05 MST 2006"
+
+// now is the clock generated files are stamped from; tests replace it.
+var now = time.Now
+
+// generated returns the time to stamp generated files with, or "" to leave
+// it out. SOURCE_DATE_EPOCH, when set, replaces the current time, as the
+// reproducible-builds convention asks.
+func (spec themeSpec) generated() (string, error) {
+	if epoch := os.Getenv("SOURCE_DATE_EPOCH"); epoch != "" {
+		secs, err := strconv.ParseInt(epoch, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid SOURCE_DATE_EPOCH %q", epoch)
+		}
+		return time.Unix(secs, 0).UTC().Format(generatedTimeFormat), nil
+	}
+	if spec.Reproducible {
+		return "", nil
+	}
+	return now().Format(generatedTimeFormat), nil
+}
+
+// scheme resolves the color roles for the spec.
+func (spec themeSpec) scheme() (*m3color.Scheme, error) {
+	chromeVariant, err := m3color.ParseVariant(spec.Variant)
+	if err != nil {
+		return nil, err
+	}
+
+	seedColor := spec.Seed
 	// Special case: handle black like Chrome does
 	if seedColor.R == 0 && seedColor.G == 0 && seedColor.B == 0 {
 		// Chrome converts black to near-black to avoid pink tones
 		seedColor = color.RGBA{1, 1, 1, 255}
 	}
-	
-	// Use Chrome's exact Material 3 algorithm
-	var chromeVariant SchemeVariant
-	switch variant {
-	case "vibrant":
-		chromeVariant = Vibrant
-	case "expressive":
-		chromeVariant = Expressive
-	case "neutral":
-		chromeVariant = Neutral
-	case "monochrome":
-		chromeVariant = Neutral // Use neutral for monochrome
-	default: // tonal_spot
-		chromeVariant = TonalSpot
-	}
-	
+
 	// Generate Chrome's Material 3 palette
-	chromePalette := GenerateChromePalette(seedColor, chromeVariant)
-	
-	// Chrome's actual browser UI tone mappings (light mode)
-	// Chrome uses kColorSysBase (neutral98) for toolbar, NOT primary colors!
-	
+	chromePalette := m3color.GenerateChromePalette(seedColor, chromeVariant)
+	scheme := m3color.NewScheme(chromePalette, spec.Mode, spec.Contrast)
+	for _, c := range spec.Custom {
+		if err := scheme.AddCustomColor(c, spec.Seed); err != nil {
+			return nil, err
+		}
+	}
+	return scheme, nil
+}
+
+func generateGTKTheme(spec themeSpec) (string, error) {
+	scheme, err := spec.scheme()
+	if err != nil {
+		return "", err
+	}
+
+	// Chrome's actual browser UI tone mappings
+	// Chrome uses kColorSysBase (neutral98 in light mode) for toolbar, NOT primary colors!
+
 	// Primary colors (for accents, highlights, focus)
-	primary := colorToHex(chromePalette.Primary.Tone(40))           // Primary accent
-	onPrimary := colorToHex(chromePalette.Primary.Tone(100))        // On Primary (white)
-	primaryContainer := colorToHex(chromePalette.Primary.Tone(90))  // Primary Container
-	onPrimaryContainer := colorToHex(chromePalette.Primary.Tone(10)) // On Primary Container
-	
+	primary := scheme.Hex("primary")                       // Primary accent
+	onPrimary := scheme.Hex("onPrimary")                   // On Primary (white in light mode)
+	primaryContainer := scheme.Hex("primaryContainer")     // Primary Container
+	onPrimaryContainer := scheme.Hex("onPrimaryContainer") // On Primary Container
+
 	// Chrome's browser chrome colors - use neutral base!
-	chromeBase := colorToHex(chromePalette.Neutral.Tone(98))         // kColorSysBase - very light!
-	chromeOnBase := colorToHex(chromePalette.Neutral.Tone(10))       // Text on base
-	
+	chromeBase := scheme.Hex("base")     // kColorSysBase - very light in light mode!
+	chromeOnBase := scheme.Hex("onBase") // Text on base
+
 	// Primary tones for highlights and accents
-	primary80 := colorToHex(chromePalette.Primary.Tone(80))         // Lighter accent
-	primary90 := colorToHex(chromePalette.Primary.Tone(90))         // Very light accent
-	
+	primary80 := scheme.Hex("primaryFixedDim") // Lighter accent (tone 80)
+	primary90 := primaryContainer              // Very light accent in light mode
+
 	// Neutral colors (Chrome's actual surface colors)
-	surface := colorToHex(chromePalette.Neutral.Tone(99))              // Surface (almost white)
-	onSurface := colorToHex(chromePalette.Neutral.Tone(10))            // On Surface (dark text)
-	
+	surface := scheme.Hex("surface")     // Surface (almost white in light mode)
+	onSurface := scheme.Hex("onSurface") // On Surface (dark text in light mode)
+
 	// Neutral Variant colors
-	surfaceVariant := colorToHex(chromePalette.NeutralVariant.Tone(90))        // Surface Variant
-	onSurfaceVariant := colorToHex(chromePalette.NeutralVariant.Tone(30))      // On Surface Variant
-	outlineVariant := colorToHex(chromePalette.NeutralVariant.Tone(80))        // Outline Variant
+	surfaceVariant := scheme.Hex("surfaceVariant")     // Surface Variant
+	onSurfaceVariant := scheme.Hex("onSurfaceVariant") // On Surface Variant
+	outlineVariant := scheme.Hex("outlineVariant")     // Outline Variant
+
+	// Semantic colors, harmonized towards the seed, and custom colors for apps
+	// and themes to reference as @success, @onWarningContainer, ...
+	var semantic strings.Builder
+	for _, role := range m3color.SemanticRoles {
+		fmt.Fprintf(&semantic, "@define-color %s %s;\n", role, scheme.Hex(role))
+	}
+	for _, c := range spec.Custom {
+		roles, err := c.Roles()
+		if err != nil {
+			return "", err
+		}
+		for _, role := range roles {
+			fmt.Fprintf(&semantic, "@define-color %s %s;\n", role, scheme.Hex(role))
+		}
+	}
+
+	generated, err := spec.generated()
+	if err != nil {
+		return "", err
+	}
+	var generatedLine string
+	if generated != "" {
+		generatedLine = fmt.Sprintf(" * Generated: %s\n", generated)
+	}
 
 	// Generate GTK CSS with Material 3 colors
 	css := fmt.Sprintf(`/*
  * Material 3 GTK Theme - Auto-generated using Material Color Utilities
  * Seed: RGB(%d,%d,%d)
  * Variant: %s
- * Generated: %s
- * 
+ * Mode: %s
+%s * 
  * This theme uses Google's Material Design 3 color system
  * with proper HCT color space calculations for harmonious colors
  */
 
+/* Semantic and custom colors */
+%s
 /* Base window styling */
 window {
     background-color: %s;      /* Material 3 surface */
@@ -121,10 +190,10 @@ window {
     background-image: none;
 }
 
-/* Header bar - Chrome uses neutral base (tone 98) for toolbar */
+/* Header bar - Chrome uses neutral base (tone 98, or 6 in dark mode) for toolbar */
 headerbar {
-    background-color: %s;       /* Chrome kColorSysBase (neutral98) */
-    color: %s;                  /* Chrome kColorSysOnBase (neutral10) */
+    background-color: %s;       /* Chrome kColorSysBase */
+    color: %s;                  /* Chrome kColorSysOnBase */
     background-image: none;
     border-color: %s;           /* Primary accent for borders */
 }
@@ -241,9 +310,12 @@ headerbar.titlebar {
     background-image: none;
 }
 `,
-		seedColor.R, seedColor.G, seedColor.B,
-		variant,
-		time.Now().Format("Mon Jan 2 15:04:05 MST 2006"),
+		spec.Seed.R, spec.Seed.G, spec.Seed.B,
+		spec.Variant,
+		scheme.Mode,
+		generatedLine,
+		// Semantic colors
+		semantic.String(),
 		// Base window
 		surface, onSurface,
 		// Header bar - use Chrome's neutral base!
@@ -286,153 +358,398 @@ headerbar.titlebar {
 		primary90, onSurface,
 	)
 
-	return css
+	return css, nil
+}
+
+// parseSeed accepts a seed color as R,G,B or as #rrggbb.
+func parseSeed(seed string) (color.RGBA, error) {
+	seed = strings.TrimSpace(seed)
+	if strings.Contains(seed, ",") {
+		r, g, b, err := parseRGB(seed)
+		return color.RGBA{r, g, b, 255}, err
+	}
+
+	hex := strings.TrimPrefix(seed, "#")
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) != 6 {
+		return color.RGBA{}, fmt.Errorf("invalid seed %q. Use R,G,B (e.g., 28,32,39) or #rrggbb", seed)
+	}
+	return color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 255}, nil
+}
+
+// options are the generator settings. They can come from flags or the config
+// file; flags that are set explicitly win.
+type options struct {
+	configPath string
+	seed       string
+	seedFrom   string
+	wallpaper  string
+	variant    string
+	mode       string
+	contrast   float64
+	output     string
+	format     string
+	preview    string
+	outputs    map[string]string
+	apply      bool
+	themeName  string
+	themesDir  string
+	system     bool
+	prefix     string
+	method     string
+	dryRun     bool
+	custom     customColors
+
+	reproducible bool
+
+	seedArg bool // the seed was given as a positional argument
+}
+
+func (o *options) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.configPath, "config", "", "Config file (default: $XDG_CONFIG_HOME/material-gtk/config.toml)")
+	fs.StringVar(&o.seed, "rgb", "", "Seed color as R,G,B (e.g., 28,32,39) or #rrggbb")
+	fs.StringVar(&o.seedFrom, "seed-from", "", "Derive the seed instead of passing one: "+strings.Join(seedSourceNames, ", "))
+	fs.StringVar(&o.wallpaper, "wallpaper", "", "Wallpaper image used with -seed-from wallpaper")
+	fs.StringVar(&o.variant, "variant", "tonal_spot", "Material 3 variant: "+strings.Join(m3color.VariantNames, ", "))
+	fs.StringVar(&o.mode, "mode", "light", "Color scheme mode: light, dark, system (follow the desktop's color-scheme)")
+	fs.Float64Var(&o.contrast, "contrast", 0, "Contrast level from -1 (reduced) to 1 (high)")
+	fs.StringVar(&o.output, "output", "", "Output file path (default: stdout)")
+	fs.StringVar(&o.format, "format", "gtk3", "Format written to -output or stdout: "+strings.Join(outputTargetNames(), ", "))
+	fs.StringVar(&o.preview, "preview", "", "Render a PNG preview of the theme to this file")
+	fs.BoolVar(&o.apply, "apply", false, "Automatically apply theme to Chrome")
+	fs.StringVar(&o.themeName, "theme-name", defaultThemeName, "Name of the installed theme (a <name>Temp theme is used for reloading)")
+	fs.StringVar(&o.themesDir, "themes-dir", "", "Directory to install themes into (default: $XDG_DATA_HOME/themes or ~/.themes)")
+	fs.BoolVar(&o.system, "system", false, "Install the theme system-wide into <prefix>/share/themes")
+	fs.StringVar(&o.prefix, "prefix", "/usr", "Installation prefix used with -system")
+	fs.StringVar(&o.method, "apply-method", "auto", "How to apply the theme: "+strings.Join(applyMethods, ", ")+" (comma separated to combine)")
+	fs.BoolVar(&o.dryRun, "dry-run", false, "Show the files and commands -output/-apply would write and run, without doing it")
+	fs.BoolVar(&o.reproducible, "reproducible", false, "Leave the generation time out of generated files so the same options give identical output (SOURCE_DATE_EPOCH, if set, is used instead)")
+	fs.Var(&o.custom, "custom", "Add a custom color role group as `NAME=COLOR`; append :harmonize to shift it towards the seed (repeatable)")
+}
+
+// load merges the config file into o. Only values whose flags were not set on
+// the command line are taken from the file. The config is returned so that
+// subcommands can read their own sections.
+func (o *options) load(fs *flag.FlagSet) (*fileConfig, error) {
+	path, explicit := o.configPath, o.configPath != ""
+	if !explicit {
+		var err error
+		if path, err = defaultConfigPath(); err != nil {
+			// Without a home directory there is no default config to read.
+			return &fileConfig{}, nil
+		}
+	}
+	cfg, err := loadConfig(path, explicit)
+	if err != nil {
+		return nil, err
+	}
+
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	// A seed or seed source given on the command line replaces both of the
+	// file's, as spec prefers seed_from over seed.
+	seedGiven := set["rgb"] || o.seedArg || set["seed-from"]
+	if !seedGiven && cfg.Seed != "" {
+		o.seed = cfg.Seed
+	}
+	if !seedGiven && cfg.SeedFrom != "" {
+		o.seedFrom = cfg.SeedFrom
+	}
+	if !set["wallpaper"] && cfg.Wallpaper != "" {
+		o.wallpaper = cfg.Wallpaper
+	}
+	if !set["variant"] && cfg.Variant != "" {
+		o.variant = cfg.Variant
+	}
+	if !set["mode"] && cfg.Mode != "" {
+		o.mode = cfg.Mode
+	}
+	if !set["contrast"] && cfg.Contrast != nil {
+		o.contrast = *cfg.Contrast
+	}
+	if !set["theme-name"] && cfg.ThemeName != "" {
+		o.themeName = cfg.ThemeName
+	}
+	if !set["themes-dir"] && cfg.ThemesDir != "" {
+		o.themesDir = cfg.ThemesDir
+	}
+	if !set["apply"] && cfg.Apply != nil {
+		o.apply = *cfg.Apply
+	}
+	if !set["apply-method"] && cfg.ApplyMethod != "" {
+		o.method = cfg.ApplyMethod
+	}
+	if !set["reproducible"] && cfg.Reproducible != nil {
+		o.reproducible = *cfg.Reproducible
+	}
+	if !set["custom"] {
+		for _, c := range cfg.Custom {
+			cc, err := newCustomColor(c.Name, c.Color, c.Harmonize)
+			if err != nil {
+				return nil, err
+			}
+			o.custom = append(o.custom, cc)
+		}
+	}
+
+	o.outputs = make(map[string]string)
+	for target, path := range cfg.Outputs {
+		o.outputs[target] = path
+	}
+	if o.format == "" {
+		o.format = "gtk3"
+	}
+	if _, ok := outputTargets[o.format]; !ok {
+		return nil, fmt.Errorf("invalid format %q (valid: %s)", o.format, strings.Join(outputTargetNames(), ", "))
+	}
+	if o.output != "" {
+		o.outputs[o.format] = o.output
+	}
+	if o.preview != "" {
+		o.outputs["preview"] = o.preview
+	}
+	return cfg, nil
+}
+
+// loadWithSeedArg takes the seed from the first positional argument, unless
+// -rgb was given, and then merges the config file like load.
+func (o *options) loadWithSeedArg(fs *flag.FlagSet) (*fileConfig, error) {
+	if o.seed == "" && fs.NArg() > 0 {
+		o.seed = fs.Arg(0)
+		o.seedArg = true
+	}
+	return o.load(fs)
+}
+
+// spec validates the color settings and turns them into a themeSpec. With
+// -seed-from the seed is read from its source.
+func (o *options) spec() (themeSpec, error) {
+	var seedColor color.RGBA
+	var err error
+	if o.seedFrom != "" {
+		seedColor, err = o.sourceSeed()
+	} else {
+		seedColor, err = parseSeed(o.seed)
+	}
+	if err != nil {
+		return themeSpec{}, err
+	}
+	if _, err := m3color.ParseVariant(o.variant); err != nil {
+		return themeSpec{}, err
+	}
+	var mode m3color.Mode
+	if o.mode == modeSystem {
+		mode, err = systemColorScheme()
+	} else {
+		if mode, err = m3color.ParseMode(o.mode); err != nil {
+			err = fmt.Errorf("invalid mode %q (valid: light, dark, %s)", o.mode, modeSystem)
+		}
+	}
+	if err != nil {
+		return themeSpec{}, err
+	}
+	if o.contrast < -1 || o.contrast > 1 {
+		return themeSpec{}, fmt.Errorf("contrast %g out of range [-1, 1]", o.contrast)
+	}
+	return themeSpec{Seed: seedColor, Variant: o.variant, Mode: mode, Contrast: o.contrast, Custom: o.custom, Reproducible: o.reproducible}, nil
+}
+
+// resolveThemesDir picks the directory themes are installed into.
+func (o *options) resolveThemesDir() (string, error) {
+	switch {
+	case o.themesDir != "":
+		return o.themesDir, nil
+	case o.system:
+		return systemThemesDir(o.prefix), nil
+	}
+	return userThemesDir()
+}
+
+// commands are the subcommands. Without one, the tool generates (and
+// optionally applies) a single theme.
+var commands = map[string]func(args []string) error{
+	"watch":        runWatch,
+	"daemon":       runDaemon,
+	"serve":        runServe,
+	"dbus-service": runService,
+	"analogous":    runAnalogous,
+	"complement":   runComplement,
+	"audit":        runAudit,
+	"simulate":     runSimulate,
+	"tui":          runTUI,
+	"batch":        runBatch,
+	"inspect":      runInspect,
 }
 
 func main() {
-	var (
-		rgbInput string
-		variant  string
-		output   string
-		apply    bool
-	)
+	if len(os.Args) > 1 {
+		if cmd, ok := commands[os.Args[1]]; ok {
+			if err := cmd(os.Args[2:]); err != nil {
+				log.Fatalf("Error: %v", err)
+			}
+			return
+		}
+	}
 
-	flag.StringVar(&rgbInput, "rgb", "", "RGB values as R,G,B (e.g., 28,32,39)")
-	flag.StringVar(&variant, "variant", "tonal_spot", "Material 3 variant: tonal_spot, vibrant, expressive, neutral, monochrome")
-	flag.StringVar(&output, "output", "", "Output file path (default: stdout)")
-	flag.BoolVar(&apply, "apply", false, "Automatically apply theme to Chrome via gsettings")
+	var opts options
+	opts.register(flag.CommandLine)
 	flag.Parse()
 
-	if rgbInput == "" && len(flag.Args()) > 0 {
-		rgbInput = flag.Args()[0]
+	if _, err := opts.loadWithSeedArg(flag.CommandLine); err != nil {
+		log.Fatalf("Error: %v", err)
 	}
 
-	if rgbInput == "" {
+	if opts.seed == "" && opts.seedFrom == "" {
 		fmt.Fprintf(os.Stderr, "Usage: %s [options] R,G,B\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s watch [options]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s daemon [options] R,G,B\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s serve [options]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s dbus-service [options]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s analogous|complement [options] R,G,B\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s audit|simulate [options] R,G,B\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s tui [options] R,G,B\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s batch [options] FILE\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s inspect [options] [THEME]\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "\nOptions:\n")
 		flag.PrintDefaults()
 		fmt.Fprintf(os.Stderr, "\nExample: %s 28,32,39\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "         %s -variant vibrant -apply 255,0,0\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "         %s -seed-from swww -apply\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\nThe seed and other options can also be set in the config file.\n")
 		os.Exit(1)
 	}
 
-	// Parse RGB input
-	r, g, b, err := parseRGB(rgbInput)
+	if err := generate(&opts); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+}
+
+// generate renders the theme described by opts, writes the configured outputs
+// and, with -apply, installs and applies it. An error from the applier is
+// returned after the theme has been installed.
+func generate(opts *options) error {
+	themeName := opts.themeName
+	if err := checkThemeName(themeName); err != nil {
+		return err
+	}
+
+	applier, err := newApplier(opts.method)
 	if err != nil {
-		log.Fatalf("Error parsing RGB: %v", err)
+		return err
 	}
 
-	seedColor := color.RGBA{r, g, b, 255}
+	spec, err := opts.spec()
+	if err != nil {
+		return err
+	}
+	seedColor := spec.Seed
 
 	// Generate GTK theme with Material 3 colors
-	css := generateGTKTheme(seedColor, variant)
+	css, err := generateGTKTheme(spec)
+	if err != nil {
+		return err
+	}
 
-	// Output the CSS
-	if output != "" {
-		// Create directory if needed
-		dir := filepath.Dir(output)
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			log.Fatalf("Failed to create directory: %v", err)
-		}
+	outputWrites, err := renderOutputs(spec, opts.outputs)
+	if err != nil {
+		return err
+	}
 
-		// Write to file
-		if err := os.WriteFile(output, []byte(css), 0644); err != nil {
-			log.Fatalf("Failed to write file: %v", err)
+	var themesDir string
+	var tempFiles, mainFiles []themeFile
+	tempThemeName := themeName + "Temp"
+	if opts.apply {
+		if themesDir, err = opts.resolveThemesDir(); err != nil {
+			return fmt.Errorf("failed to locate themes directory: %w", err)
+		}
+		if tempFiles, err = themeFiles(tempThemeName, css, spec, opts.outputs); err != nil {
+			return err
+		}
+		if mainFiles, err = themeFiles(themeName, css, spec, opts.outputs); err != nil {
+			return err
 		}
-		fmt.Printf("✅ Theme written to %s\n", output)
-	} else if !apply {
-		// Print to stdout if not applying
-		fmt.Print(css)
 	}
 
-	// Apply theme if requested
-	if apply {
-		// First, write to temporary theme
-		tempThemePath := filepath.Join(os.Getenv("HOME"), ".themes/OmarchyThemeTemp/gtk-3.0/gtk.css")
-		
-		// Create temp theme directory
-		tempThemeDir := filepath.Dir(tempThemePath)
-		if err := os.MkdirAll(tempThemeDir, 0755); err != nil {
-			log.Fatalf("Failed to create temp theme directory: %v", err)
+	if opts.dryRun {
+		writes := outputWrites
+		var commands []string
+		if opts.apply {
+			writes = append(writes, themeWrites(themesDir, tempThemeName, tempFiles)...)
+			writes = append(writes, themeWrites(themesDir, themeName, mainFiles)...)
+			commands = applier.Describe(themeName, tempThemeName)
 		}
-
-		// Write temp theme file
-		if err := os.WriteFile(tempThemePath, []byte(css), 0644); err != nil {
-			log.Fatalf("Failed to write temp theme file: %v", err)
+		if err := printDryRun(os.Stdout, writes, commands); err != nil {
+			return fmt.Errorf("dry run failed: %w", err)
 		}
+		return nil
+	}
 
-		// Create temp index.theme
-		tempIndexPath := filepath.Join(os.Getenv("HOME"), ".themes/OmarchyThemeTemp/index.theme")
-		tempIndexContent := fmt.Sprintf(`[Desktop Entry]
-Type=X-GNOME-Metatheme
-Name=OmarchyThemeTemp
-Comment=Material 3 Theme Temp - RGB(%d,%d,%d)
-Encoding=UTF-8
-
-[X-GNOME-Metatheme]
-GtkTheme=OmarchyThemeTemp
-IconTheme=Adwaita
-CursorTheme=Adwaita
-`, r, g, b)
-		
-		if err := os.WriteFile(tempIndexPath, []byte(tempIndexContent), 0644); err != nil {
-			log.Fatalf("Failed to write temp index.theme: %v", err)
+	// Output the CSS
+	for _, w := range outputWrites {
+		if err := writeFileAtomic(w.Path, w.Content, 0644); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
 		}
-
-		// Now write to main theme
-		mainThemePath := filepath.Join(os.Getenv("HOME"), ".themes/OmarchyTheme/gtk-3.0/gtk.css")
-		
-		// Create main theme directory
-		mainThemeDir := filepath.Dir(mainThemePath)
-		if err := os.MkdirAll(mainThemeDir, 0755); err != nil {
-			log.Fatalf("Failed to create main theme directory: %v", err)
+		fmt.Printf("✅ Theme written to %s\n", w.Path)
+	}
+	if len(outputWrites) == 0 && !opts.apply {
+		// Print to stdout if not applying
+		if opts.format == "gtk3" {
+			fmt.Print(css)
+		} else {
+			content, err := outputTargets[opts.format](spec)
+			if err != nil {
+				return fmt.Errorf("failed to render %s output: %w", opts.format, err)
+			}
+			os.Stdout.Write(content)
 		}
+	}
 
-		// Write main theme file
-		if err := os.WriteFile(mainThemePath, []byte(css), 0644); err != nil {
-			log.Fatalf("Failed to write main theme file: %v", err)
+	// Apply theme if requested
+	if opts.apply {
+		// The temporary theme is installed first so we can toggle through it
+		tempDir, err := installTheme(themesDir, tempThemeName, tempFiles)
+		if err != nil {
+			return fmt.Errorf("failed to install temp theme: %w", err)
 		}
 
-		// Create main index.theme
-		mainIndexPath := filepath.Join(os.Getenv("HOME"), ".themes/OmarchyTheme/index.theme")
-		mainIndexContent := fmt.Sprintf(`[Desktop Entry]
-Type=X-GNOME-Metatheme
-Name=OmarchyTheme
-Comment=Material 3 Theme - RGB(%d,%d,%d)
-Encoding=UTF-8
-
-[X-GNOME-Metatheme]
-GtkTheme=OmarchyTheme
-IconTheme=Adwaita
-CursorTheme=Adwaita
-`, r, g, b)
-		
-		if err := os.WriteFile(mainIndexPath, []byte(mainIndexContent), 0644); err != nil {
-			log.Fatalf("Failed to write main index.theme: %v", err)
+		mainDir, err := installTheme(themesDir, themeName, mainFiles)
+		if err != nil {
+			return fmt.Errorf("failed to install main theme: %w", err)
 		}
 
-		fmt.Printf("🎨 Material 3 theme created with RGB(%d,%d,%d)\n", r, g, b)
-		fmt.Printf("   Variant: %s\n", variant)
+		fmt.Printf("🎨 Material 3 theme created with RGB(%d,%d,%d)\n", seedColor.R, seedColor.G, seedColor.B)
+		fmt.Printf("   Variant: %s\n", spec.Variant)
+		fmt.Printf("   Mode: %s\n", spec.Mode)
 		fmt.Printf("   Seed color: %s\n", argbToHex(rgbaToARGB(seedColor)))
-		fmt.Printf("✅ Themes saved to ~/.themes/OmarchyTheme and ~/.themes/OmarchyThemeTemp\n")
-		
+		fmt.Printf("✅ Themes saved to %s and %s\n", mainDir, tempDir)
+
 		// Trigger Chrome to reload by switching between our own themes (no flicker)
-		fmt.Println("🔄 Triggering theme reload...")
-		cmd := exec.Command("gsettings", "set", "org.gnome.desktop.interface", "gtk-theme", "OmarchyThemeTemp")
-		if err := cmd.Run(); err != nil {
-			log.Printf("Warning: Failed to switch to OmarchyThemeTemp: %v", err)
-		}
-		
-		// Wait 1 second to ensure the switch is registered
-		time.Sleep(1 * time.Second)
-		
-		cmd = exec.Command("gsettings", "set", "org.gnome.desktop.interface", "gtk-theme", "OmarchyTheme")
-		if err := cmd.Run(); err != nil {
-			log.Printf("Warning: Failed to switch back to OmarchyTheme: %v", err)
+		fmt.Printf("🔄 Triggering theme reload via %s...\n", applier.Name())
+		if err := applier.Apply(themeName, tempThemeName); err != nil {
+			return fmt.Errorf("theme installed but not applied: %w", err)
 		}
-		
+
 		fmt.Println("🎉 Chrome should now display with your Material 3 colors!")
 		fmt.Println("\nTo use this theme permanently, make sure 'Use GTK+ theme' is enabled")
 		fmt.Println("in chrome://settings/appearance")
 	}
-}
\ No newline at end of file
+	return nil
+}
+
+// renderOutputs renders every configured output target, in a stable order.
+func renderOutputs(spec themeSpec, outputs map[string]string) ([]plannedWrite, error) {
+	var writes []plannedWrite
+	for _, target := range outputTargetNames() {
+		path, ok := outputs[target]
+		if !ok {
+			continue
+		}
+		content, err := outputTargets[target](spec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render %s output: %w", target, err)
+		}
+		writes = append(writes, plannedWrite{Path: path, Content: content})
+	}
+	return writes, nil
+}
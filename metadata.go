@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strings"
+)
+
+// metadataFile is written into every theme directory so that inspect can
+// tell how the theme was made and regenerate it.
+const metadataFile = "material-gtk.json"
+
+// version is the tool version recorded in theme metadata. Release builds set
+// it with -ldflags "-X main.version=v1.2.3"; otherwise the module version
+// from the build info is used.
+var version = ""
+
+// toolVersion returns version, the module version or "devel".
+func toolVersion() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "devel"
+}
+
+// themeMetadata is the content of metadataFile: everything needed to
+// generate the theme again.
+type themeMetadata struct {
+	Tool         string            `json:"tool"`
+	Version      string            `json:"version"`
+	Generated    string            `json:"generated,omitempty"`
+	Reproducible bool              `json:"reproducible,omitempty"`
+	Seed         string            `json:"seed"`
+	Variant      string            `json:"variant"`
+	Mode         string            `json:"mode"`
+	Contrast     float64           `json:"contrast"`
+	CustomColors []customConfig    `json:"customColors,omitempty"`
+	Outputs      map[string]string `json:"outputs,omitempty"`
+}
+
+// newThemeMetadata describes a theme generated from spec that also wrote
+// outputs. Output paths are made absolute so the theme can be regenerated
+// from any directory.
+func newThemeMetadata(spec themeSpec, outputs map[string]string) (themeMetadata, error) {
+	generated, err := spec.generated()
+	if err != nil {
+		return themeMetadata{}, err
+	}
+	meta := themeMetadata{
+		Tool:         "material-gtk",
+		Version:      toolVersion(),
+		Generated:    generated,
+		Reproducible: spec.Reproducible,
+		Seed:         colorToHex(spec.Seed),
+		Variant:      spec.Variant,
+		Mode:         spec.Mode.String(),
+		Contrast:     spec.Contrast,
+	}
+	for _, c := range spec.Custom {
+		meta.CustomColors = append(meta.CustomColors, customConfig{Name: c.Name, Color: colorToHex(c.Value), Harmonize: c.Harmonize})
+	}
+	if len(outputs) > 0 {
+		meta.Outputs = make(map[string]string, len(outputs))
+		for target, path := range outputs {
+			if abs, err := filepath.Abs(path); err == nil {
+				path = abs
+			}
+			meta.Outputs[target] = path
+		}
+	}
+	return meta, nil
+}
+
+// readThemeMetadata reads the metadata of the theme in dir.
+func readThemeMetadata(dir string) (themeMetadata, error) {
+	var meta themeMetadata
+	if _, err := os.Stat(dir); err != nil {
+		return meta, fmt.Errorf("theme not found: %w", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, metadataFile))
+	if os.IsNotExist(err) {
+		return meta, fmt.Errorf("%s has no %s; it was not generated by this version of material-gtk", dir, metadataFile)
+	}
+	if err != nil {
+		return meta, err
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return meta, fmt.Errorf("failed to parse %s: %w", filepath.Join(dir, metadataFile), err)
+	}
+	return meta, nil
+}
+
+// options turns the metadata back into generator settings.
+func (meta themeMetadata) options() (options, error) {
+	opts := options{
+		seed:         meta.Seed,
+		variant:      meta.Variant,
+		mode:         meta.Mode,
+		contrast:     meta.Contrast,
+		reproducible: meta.Reproducible,
+		outputs:      meta.Outputs,
+	}
+	for _, c := range meta.CustomColors {
+		cc, err := newCustomColor(c.Name, c.Color, c.Harmonize)
+		if err != nil {
+			return options{}, err
+		}
+		opts.custom = append(opts.custom, cc)
+	}
+	for target := range meta.Outputs {
+		if _, ok := outputTargets[target]; !ok {
+			return options{}, fmt.Errorf("unknown output target %q (valid: %s)", target, strings.Join(outputTargetNames(), ", "))
+		}
+	}
+	return opts, nil
+}
+
+// outputTargetList returns the recorded output targets in a stable order.
+func (meta themeMetadata) outputTargetList() []string {
+	targets := make([]string, 0, len(meta.Outputs))
+	for target := range meta.Outputs {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	return targets
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// readTree returns the content of every file below dir, by relative path.
+func readTree(t *testing.T, dir string) map[string][]byte {
+	t.Helper()
+	files := make(map[string][]byte)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[rel], err = os.ReadFile(path)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestMetadataRoundTrip(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "")
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	config := filepath.Join(dir, "config.toml")
+	if err := os.WriteFile(config, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	themesDir := filepath.Join(dir, "themes")
+	outDir := filepath.Join(dir, "out")
+	jsonOut := filepath.Join(outDir, "scheme.json")
+	previewOut := filepath.Join(outDir, "preview.png")
+
+	opts := loadTestOptions(t, "",
+		"-rgb", "200,100,50", "-variant", "vibrant", "-mode", "dark", "-contrast", "0.5",
+		"-custom", "link=#1a73e8:harmonize", "-custom", "badge=#ff00aa",
+		"-format", "json", "-output", jsonOut, "-preview", previewOut,
+		"-reproducible", "-apply", "-apply-method", "settings-ini",
+		"-theme-name", "Round", "-themes-dir", themesDir)
+	if err := generate(&opts); err != nil {
+		t.Fatal(err)
+	}
+	themeDir := filepath.Join(themesDir, "Round")
+	installed := readTree(t, themeDir)
+	outputs := readTree(t, outDir)
+
+	meta, err := readThemeMetadata(themeDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := themeMetadata{
+		Tool:         "material-gtk",
+		Version:      toolVersion(),
+		Reproducible: true,
+		Seed:         "#c86432",
+		Variant:      "vibrant",
+		Mode:         "dark",
+		Contrast:     0.5,
+		CustomColors: []customConfig{
+			{Name: "link", Color: "#1a73e8", Harmonize: true},
+			{Name: "badge", Color: "#ff00aa"},
+		},
+		Outputs: map[string]string{"json": jsonOut, "preview": previewOut},
+	}
+	if !reflect.DeepEqual(meta, want) {
+		t.Errorf("metadata = %+v, want %+v", meta, want)
+	}
+
+	// Damage the installed files and outputs, then regenerate through the
+	// inspect command.
+	for _, path := range []string{filepath.Join(themeDir, "gtk-3.0", "gtk.css"), jsonOut} {
+		if err := os.WriteFile(path, []byte("stale"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Remove(previewOut); err != nil {
+		t.Fatal(err)
+	}
+	if err := runInspect([]string{"-config", config, "-themes-dir", themesDir, "-regenerate", "Round"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, got := range map[string]map[string][]byte{
+		themeDir: readTree(t, themeDir),
+		outDir:   readTree(t, outDir),
+	} {
+		before := installed
+		if name == outDir {
+			before = outputs
+		}
+		if len(got) != len(before) {
+			t.Errorf("%s holds %d files after regenerating, %d before", name, len(got), len(before))
+		}
+		for file, content := range before {
+			if !bytes.Equal(got[file], content) {
+				t.Errorf("%s differs after regenerating", filepath.Join(name, file))
+			}
+		}
+	}
+}
+
+func TestReadThemeMetadataErrors(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "index.theme"), []byte("[Desktop Entry]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := readThemeMetadata(dir)
+	if err == nil || !strings.Contains(err.Error(), "has no material-gtk.json") {
+		t.Errorf("theme without metadata: err = %v", err)
+	}
+
+	_, err = readThemeMetadata(filepath.Join(dir, "missing"))
+	if err == nil || !strings.HasPrefix(err.Error(), "theme not found") {
+		t.Errorf("missing theme: err = %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, metadataFile), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = readThemeMetadata(dir)
+	if err == nil || !strings.Contains(err.Error(), "failed to parse") {
+		t.Errorf("malformed metadata: err = %v", err)
+	}
+}
+
+func TestMetadataOptionsErrors(t *testing.T) {
+	for _, meta := range []themeMetadata{
+		{Seed: "#000000", Outputs: map[string]string{"pdf": "/tmp/x.pdf"}},
+		{Seed: "#000000", CustomColors: []customConfig{{Name: "link", Color: "blue"}}},
+	} {
+		if _, err := meta.options(); err == nil {
+			t.Errorf("options() accepted %+v", meta)
+		}
+	}
+}
+
+func TestInspectedThemeDir(t *testing.T) {
+	themesDir := t.TempDir()
+	opts := options{themesDir: themesDir, themeName: "Configured"}
+	tests := []struct {
+		theme, want string
+	}{
+		{"", filepath.Join(themesDir, "Configured")},
+		{"Other", filepath.Join(themesDir, "Other")},
+		{"./Local", "./Local"},
+		{"/abs/Theme", "/abs/Theme"},
+	}
+	for _, tt := range tests {
+		got, err := inspectedThemeDir(&opts, tt.theme)
+		if err != nil || got != tt.want {
+			t.Errorf("inspectedThemeDir(%q) = %q, %v; want %q", tt.theme, got, err, tt.want)
+		}
+	}
+	for _, theme := range []string{".", ".."} {
+		if _, err := inspectedThemeDir(&opts, theme); err == nil {
+			t.Errorf("inspectedThemeDir(%q) accepted it", theme)
+		}
+	}
+}
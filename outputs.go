@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+
+	"material-gtk/pkg/m3color"
+)
+
+// outputTargets renders each kind of file that can be written with -output or
+// the [outputs] table of the config file.
+var outputTargets = map[string]func(spec themeSpec) ([]byte, error){
+	"gtk3": func(spec themeSpec) ([]byte, error) {
+		css, err := generateGTKTheme(spec)
+		return []byte(css), err
+	},
+	"json": func(spec themeSpec) ([]byte, error) {
+		return schemeJSON(spec)
+	},
+	"preview": previewPNG,
+	"html":    htmlReport,
+}
+
+func outputTargetNames() []string {
+	names := make([]string, 0, len(outputTargets))
+	for name := range outputTargets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// schemeDocument is the JSON representation of a generated scheme.
+type schemeDocument struct {
+	Seed     string            `json:"seed"`
+	Variant  string            `json:"variant"`
+	Mode     string            `json:"mode"`
+	Contrast float64           `json:"contrast"`
+	Roles    map[string]string `json:"roles"`
+	// Palettes maps each palette to its colors at the standard tone stops,
+	// keyed by tone.
+	Palettes map[string]map[string]string `json:"palettes"`
+	// KeyColors holds the displayable color representing each palette.
+	KeyColors map[string]string `json:"keyColors"`
+}
+
+func newSchemeDocument(spec themeSpec) (*schemeDocument, error) {
+	scheme, err := spec.scheme()
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &schemeDocument{
+		Seed:     colorToHex(spec.Seed),
+		Variant:  spec.Variant,
+		Mode:     scheme.Mode.String(),
+		Contrast: scheme.Contrast,
+		Roles:    make(map[string]string, len(scheme.Roles())),
+	}
+	for _, role := range scheme.Roles() {
+		doc.Roles[role] = scheme.Hex(role)
+	}
+	doc.Palettes = make(map[string]map[string]string)
+	doc.KeyColors = make(map[string]string)
+	for _, p := range append(scheme.Palette.Palettes(), scheme.Custom...) {
+		tones := make(map[string]string, len(m3color.ToneStops))
+		for _, stop := range p.Palette.Stops() {
+			tones[strconv.FormatFloat(stop.Tone, 'g', -1, 64)] = colorToHex(stop.Color)
+		}
+		doc.Palettes[p.Name] = tones
+		doc.KeyColors[p.Name] = colorToHex(p.Palette.KeyColor().ToRGB())
+	}
+	return doc, nil
+}
+
+func schemeJSON(spec themeSpec) ([]byte, error) {
+	doc, err := newSchemeDocument(spec)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
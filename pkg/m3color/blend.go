@@ -0,0 +1,54 @@
+package m3color
+
+import (
+	"image/color"
+	"math"
+)
+
+// Semantic design colors before harmonization. Error is Chrome's error
+// color; success, warning and info follow the same chroma range.
+var (
+	errorDesign   = HCT{Hue: 25, Chroma: 84}
+	successDesign = HCT{Hue: 130, Chroma: 72}
+	warningDesign = HCT{Hue: 45, Chroma: 96}
+	infoDesign    = HCT{Hue: 210, Chroma: 72}
+)
+
+// Harmonize shifts the hue of design towards source, as Material's
+// Blend.harmonize does: by half the hue difference, at most 15 degrees.
+// Chroma and tone are kept, so a red stays recognisably red.
+func Harmonize(design, source color.RGBA) color.RGBA {
+	d := RGBToHCT(design.R, design.G, design.B)
+	s := RGBToHCT(source.R, source.G, source.B)
+	d.Hue = harmonizeHue(d.Hue, s)
+	return d.ToRGB()
+}
+
+// harmonizeHue returns designHue rotated towards source's hue. Greys have no
+// meaningful hue, so they leave designHue alone.
+func harmonizeHue(designHue float64, source HCT) float64 {
+	if source.Chroma < 1 {
+		return designHue
+	}
+	rotation := math.Min(differenceDegrees(designHue, source.Hue)*0.5, 15)
+	return sanitizeDegreesDouble(designHue + rotation*rotationDirection(designHue, source.Hue))
+}
+
+// differenceDegrees is the distance between two hues, at most 180.
+func differenceDegrees(a, b float64) float64 {
+	return 180 - math.Abs(math.Abs(a-b)-180)
+}
+
+// rotationDirection is 1 if the shortest way from one hue to another is
+// clockwise (increasing), -1 otherwise.
+func rotationDirection(from, to float64) float64 {
+	if sanitizeDegreesDouble(to-from) <= 180 {
+		return 1
+	}
+	return -1
+}
+
+// semanticPalette is the palette of a design color harmonized towards source.
+func semanticPalette(design, source HCT) TonalPalette {
+	return newTonalPalette(harmonizeHue(design.Hue, source), design.Chroma)
+}
@@ -0,0 +1,78 @@
+package m3color
+
+import (
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestHarmonizeHue(t *testing.T) {
+	tests := []struct {
+		name       string
+		design     float64
+		source     HCT
+		wantDesign float64
+	}{
+		{"half the difference", 25, HCT{Hue: 35, Chroma: 50}, 30},
+		{"counter-clockwise", 25, HCT{Hue: 5, Chroma: 50}, 15},
+		{"capped at 15 degrees", 25, HCT{Hue: 200, Chroma: 50}, 40},
+		{"capped, counter-clockwise", 25, HCT{Hue: 250, Chroma: 50}, 10},
+		{"across 360 clockwise", 350, HCT{Hue: 10, Chroma: 50}, 0},
+		{"across 0 counter-clockwise", 5, HCT{Hue: 340, Chroma: 50}, 352.5},
+		{"across 0, capped", 340, HCT{Hue: 100, Chroma: 50}, 355},
+		{"same hue", 130, HCT{Hue: 130, Chroma: 50}, 130},
+		{"grey source", 25, HCT{Hue: 200, Chroma: 0.5}, 25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := harmonizeHue(tt.design, tt.source); math.Abs(got-tt.wantDesign) > 1e-9 {
+				t.Errorf("harmonizeHue(%v, %+v) = %v, want %v", tt.design, tt.source, got, tt.wantDesign)
+			}
+		})
+	}
+}
+
+func TestHarmonize(t *testing.T) {
+	sources := []color.RGBA{
+		{0x00, 0x00, 0xff, 255}, // far away: the 15 degree cap applies
+		{0xff, 0x80, 0x00, 255}, // close by
+		{0xff, 0x00, 0x40, 255}, // across 0/360 from a red-orange
+		{0x80, 0x80, 0x80, 255}, // grey
+	}
+	designs := []color.RGBA{
+		{0xb3, 0x26, 0x1e, 255}, // error red
+		{0x2e, 0x7d, 0x32, 255}, // success green
+		{0xff, 0x40, 0x00, 255},
+	}
+	for _, source := range sources {
+		for _, design := range designs {
+			got := Harmonize(design, source)
+			d, g := RGBToHCT(design.R, design.G, design.B), RGBToHCT(got.R, got.G, got.B)
+			s := RGBToHCT(source.R, source.G, source.B)
+
+			// 8-bit rounding moves each coordinate by up to about one unit.
+			if math.Abs(d.Chroma-g.Chroma) > 1 || math.Abs(d.Tone-g.Tone) > 1 {
+				t.Errorf("Harmonize(%v, %v) = %v: chroma/tone %.1f/%.1f, want %.1f/%.1f", design, source, got, g.Chroma, g.Tone, d.Chroma, d.Tone)
+			}
+			shift := differenceDegrees(d.Hue, g.Hue)
+			want := math.Min(differenceDegrees(d.Hue, s.Hue)*0.5, 15)
+			if s.Chroma < 1 {
+				want = 0
+			}
+			if math.Abs(shift-want) > 1.5 {
+				t.Errorf("Harmonize(%v, %v) = %v: hue moved %.1f°, want %.1f°", design, source, got, shift, want)
+			}
+			if want > 1.5 && differenceDegrees(g.Hue, s.Hue) >= differenceDegrees(d.Hue, s.Hue) {
+				t.Errorf("Harmonize(%v, %v) = %v: hue moved away from the source", design, source, got)
+			}
+		}
+	}
+}
+
+func TestSemanticPalette(t *testing.T) {
+	source := RGBToHCT(0x00, 0x00, 0xff)
+	tp := semanticPalette(errorDesign, source)
+	if want := harmonizeHue(errorDesign.Hue, source); tp.Hue() != want || tp.Chroma() != errorDesign.Chroma {
+		t.Errorf("semanticPalette(error) = hue %v chroma %v, want hue %v chroma %v", tp.Hue(), tp.Chroma(), want, errorDesign.Chroma)
+	}
+}
@@ -0,0 +1,58 @@
+package m3color
+
+import (
+	"image/color"
+	"math"
+)
+
+// ContrastRatio returns the WCAG 2.x contrast ratio of two colors, from 1
+// (none) to 21 (black on white). The order of the colors does not matter.
+func ContrastRatio(a, b color.RGBA) float64 {
+	la, lb := relativeLuminance(a), relativeLuminance(b)
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// relativeLuminance is the WCAG 2.x relative luminance of c.
+func relativeLuminance(c color.RGBA) float64 {
+	return 0.2126*linearize(c.R) + 0.7152*linearize(c.G) + 0.0722*linearize(c.B)
+}
+
+// APCAContrast returns the APCA (0.0.98G-4g) lightness contrast Lc of text
+// on background. Dark text on a light background is positive, light text on
+// a dark background negative; |Lc| 60 is the usual minimum for body text.
+func APCAContrast(text, background color.RGBA) float64 {
+	yText, yBackground := apcaLuminance(text), apcaLuminance(background)
+	if math.Abs(yBackground-yText) < 0.0005 {
+		return 0
+	}
+
+	const scale, offset, clip = 1.14, 0.027, 0.1
+	if yBackground > yText {
+		sapc := (math.Pow(yBackground, 0.56) - math.Pow(yText, 0.57)) * scale
+		if sapc < clip {
+			return 0
+		}
+		return (sapc - offset) * 100
+	}
+	sapc := (math.Pow(yBackground, 0.65) - math.Pow(yText, 0.62)) * scale
+	if sapc > -clip {
+		return 0
+	}
+	return (sapc + offset) * 100
+}
+
+// apcaLuminance is APCA's estimated screen luminance, with the soft clamp
+// for near-black colors.
+func apcaLuminance(c color.RGBA) float64 {
+	channel := func(v uint8) float64 { return math.Pow(float64(v)/255, 2.4) }
+	y := 0.2126729*channel(c.R) + 0.7151522*channel(c.G) + 0.0721750*channel(c.B)
+
+	const blackThreshold, blackClamp = 0.022, 1.414
+	if y < blackThreshold {
+		y += math.Pow(blackThreshold-y, blackClamp)
+	}
+	return y
+}
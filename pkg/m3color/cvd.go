@@ -0,0 +1,167 @@
+package m3color
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strings"
+)
+
+// Deficiency is a type of color vision deficiency.
+type Deficiency int
+
+const (
+	Protanopia    Deficiency = iota // no long-wavelength (red) cones
+	Deuteranopia                    // no medium-wavelength (green) cones
+	Tritanopia                      // no short-wavelength (blue) cones
+	Achromatopsia                   // no color vision at all
+)
+
+// Deficiencies lists every Deficiency, in declaration order.
+var Deficiencies = []Deficiency{Protanopia, Deuteranopia, Tritanopia, Achromatopsia}
+
+var deficiencyNames = []string{"protanopia", "deuteranopia", "tritanopia", "achromatopsia"}
+
+// String returns the lowercase name of the deficiency.
+func (d Deficiency) String() string {
+	if int(d) < len(deficiencyNames) {
+		return deficiencyNames[d]
+	}
+	return fmt.Sprintf("Deficiency(%d)", int(d))
+}
+
+// ParseDeficiency parses a deficiency name as returned by String.
+func ParseDeficiency(s string) (Deficiency, error) {
+	for i, name := range deficiencyNames {
+		if strings.EqualFold(s, name) {
+			return Deficiency(i), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid deficiency %q (valid: %s)", s, strings.Join(deficiencyNames, ", "))
+}
+
+// machadoMatrices are the full-severity dichromacy matrices of Machado,
+// Oliveira and Fernandes (2009), applied to linear RGB.
+var machadoMatrices = map[Deficiency][3][3]float64{
+	Protanopia: {
+		{0.152286, 1.052583, -0.204868},
+		{0.114503, 0.786281, 0.099216},
+		{-0.003882, -0.048116, 1.051998},
+	},
+	Deuteranopia: {
+		{0.367322, 0.860646, -0.227968},
+		{0.280085, 0.672501, 0.047413},
+		{-0.011820, 0.042940, 0.968881},
+	},
+	Tritanopia: {
+		{1.255528, -0.076749, -0.178779},
+		{-0.078411, 0.930809, 0.147602},
+		{0.004733, 0.691367, 0.303900},
+	},
+}
+
+// Simulate returns c as seen with deficiency d.
+func Simulate(c color.RGBA, d Deficiency) color.RGBA {
+	r, g, b := linearize(c.R), linearize(c.G), linearize(c.B)
+
+	if d == Achromatopsia {
+		y := 0.2126*r + 0.7152*g + 0.0722*b
+		v := delinearize(y)
+		return color.RGBA{v, v, v, 255}
+	}
+
+	m, ok := machadoMatrices[d]
+	if !ok {
+		return c
+	}
+	return color.RGBA{
+		R: delinearize(m[0][0]*r + m[0][1]*g + m[0][2]*b),
+		G: delinearize(m[1][0]*r + m[1][1]*g + m[1][2]*b),
+		B: delinearize(m[2][0]*r + m[2][1]*g + m[2][2]*b),
+		A: 255,
+	}
+}
+
+// linearize converts an sRGB channel to linear light in [0, 1].
+func linearize(v uint8) float64 {
+	f := float64(v) / 255
+	if f <= 0.04045 {
+		return f / 12.92
+	}
+	return math.Pow((f+0.055)/1.055, 2.4)
+}
+
+// delinearize converts linear light to an sRGB channel, clamping to gamut.
+func delinearize(f float64) uint8 {
+	f = math.Max(0, math.Min(1, f))
+	if f <= 0.0031308 {
+		f *= 12.92
+	} else {
+		f = 1.055*math.Pow(f, 1/2.4) - 0.055
+	}
+	return uint8(math.Round(f * 255))
+}
+
+// DeltaE returns the CIEDE2000 color difference of a and b. Around 2 is just
+// noticeable side by side; below 10 colors are easily confused at a glance.
+func DeltaE(a, b color.RGBA) float64 {
+	l1, a1, b1 := labFromRGB(a)
+	l2, a2, b2 := labFromRGB(b)
+	return deltaE2000(l1, a1, b1, l2, a2, b2)
+}
+
+func deltaE2000(l1, a1, b1, l2, a2, b2 float64) float64 {
+	deg := math.Pi / 180
+	c1, c2 := math.Hypot(a1, b1), math.Hypot(a2, b2)
+	cMean := (c1 + c2) / 2
+	g := 0.5 * (1 - math.Sqrt(math.Pow(cMean, 7)/(math.Pow(cMean, 7)+math.Pow(25, 7))))
+	a1p, a2p := (1+g)*a1, (1+g)*a2
+	c1p, c2p := math.Hypot(a1p, b1), math.Hypot(a2p, b2)
+
+	hue := func(b, ap float64) float64 {
+		if b == 0 && ap == 0 {
+			return 0
+		}
+		return sanitizeDegreesDouble(math.Atan2(b, ap) / deg)
+	}
+	h1p, h2p := hue(b1, a1p), hue(b2, a2p)
+
+	dLp := l2 - l1
+	dCp := c2p - c1p
+	var dhp float64
+	if c1p*c2p != 0 {
+		dhp = h2p - h1p
+		if dhp > 180 {
+			dhp -= 360
+		} else if dhp < -180 {
+			dhp += 360
+		}
+	}
+	dHp := 2 * math.Sqrt(c1p*c2p) * math.Sin(dhp/2*deg)
+
+	lMean := (l1 + l2) / 2
+	cpMean := (c1p + c2p) / 2
+	hpMean := h1p + h2p
+	if c1p*c2p != 0 {
+		if math.Abs(h1p-h2p) > 180 {
+			if hpMean < 360 {
+				hpMean += 360
+			} else {
+				hpMean -= 360
+			}
+		}
+		hpMean /= 2
+	}
+
+	t := 1 - 0.17*math.Cos((hpMean-30)*deg) + 0.24*math.Cos(2*hpMean*deg) +
+		0.32*math.Cos((3*hpMean+6)*deg) - 0.20*math.Cos((4*hpMean-63)*deg)
+	dTheta := 30 * math.Exp(-math.Pow((hpMean-275)/25, 2))
+	rc := 2 * math.Sqrt(math.Pow(cpMean, 7)/(math.Pow(cpMean, 7)+math.Pow(25, 7)))
+	sl := 1 + 0.015*math.Pow(lMean-50, 2)/math.Sqrt(20+math.Pow(lMean-50, 2))
+	sc := 1 + 0.045*cpMean
+	sh := 1 + 0.015*cpMean*t
+	rt := -math.Sin(2*dTheta*deg) * rc
+
+	return math.Sqrt(math.Pow(dLp/sl, 2) + math.Pow(dCp/sc, 2) + math.Pow(dHp/sh, 2) +
+		rt*(dCp/sc)*(dHp/sh))
+}
@@ -0,0 +1,97 @@
+package m3color
+
+import (
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestSimulate(t *testing.T) {
+	// The Machado et al. (2009) full-severity matrices applied in linear RGB,
+	// computed independently of this package.
+	tests := []struct {
+		input color.RGBA
+		want  map[Deficiency]color.RGBA
+	}{
+		{color.RGBA{255, 0, 0, 255}, map[Deficiency]color.RGBA{
+			Protanopia:    {109, 95, 0, 255},
+			Deuteranopia:  {163, 144, 0, 255},
+			Tritanopia:    {255, 0, 15, 255},
+			Achromatopsia: {127, 127, 127, 255},
+		}},
+		{color.RGBA{0, 255, 0, 255}, map[Deficiency]color.RGBA{
+			Protanopia:    {255, 229, 0, 255},
+			Deuteranopia:  {239, 214, 58, 255},
+			Tritanopia:    {0, 247, 217, 255},
+			Achromatopsia: {220, 220, 220, 255},
+		}},
+		{color.RGBA{0, 0, 255, 255}, map[Deficiency]color.RGBA{
+			Protanopia:    {0, 89, 255, 255},
+			Deuteranopia:  {0, 61, 251, 255},
+			Tritanopia:    {0, 107, 150, 255},
+			Achromatopsia: {76, 76, 76, 255},
+		}},
+		{color.RGBA{0x33, 0x66, 0x99, 255}, map[Deficiency]color.RGBA{
+			Protanopia:    {80, 105, 155, 255},
+			Deuteranopia:  {66, 95, 152, 255},
+			Tritanopia:    {0, 114, 120, 255},
+			Achromatopsia: {99, 99, 99, 255},
+		}},
+		{color.RGBA{255, 255, 255, 255}, map[Deficiency]color.RGBA{
+			Protanopia:    {255, 255, 255, 255},
+			Deuteranopia:  {255, 255, 255, 255},
+			Tritanopia:    {255, 255, 255, 255},
+			Achromatopsia: {255, 255, 255, 255},
+		}},
+	}
+	for _, tt := range tests {
+		for _, d := range Deficiencies {
+			if got := Simulate(tt.input, d); got != tt.want[d] {
+				t.Errorf("Simulate(%v, %s) = %v, want %v", tt.input, d, got, tt.want[d])
+			}
+		}
+	}
+
+	if c := (color.RGBA{1, 2, 3, 255}); Simulate(c, Deficiency(99)) != c {
+		t.Error("an unknown deficiency should leave the color alone")
+	}
+}
+
+func TestParseDeficiency(t *testing.T) {
+	for _, d := range Deficiencies {
+		if got, err := ParseDeficiency(d.String()); err != nil || got != d {
+			t.Errorf("ParseDeficiency(%q) = %v, %v", d.String(), got, err)
+		}
+	}
+	if got, err := ParseDeficiency("Deuteranopia"); err != nil || got != Deuteranopia {
+		t.Errorf("ParseDeficiency is not case-insensitive: %v, %v", got, err)
+	}
+	if _, err := ParseDeficiency("colorblind"); err == nil {
+		t.Error("ParseDeficiency accepted an unknown name")
+	}
+}
+
+func TestDeltaE2000(t *testing.T) {
+	// Pairs from Sharma, Wu and Dalal's CIEDE2000 test data.
+	tests := []struct {
+		lab1, lab2 [3]float64
+		want       float64
+	}{
+		{[3]float64{50, 2.6772, -79.7751}, [3]float64{50, 0, -82.7485}, 2.0425},
+		{[3]float64{50, 0, 0}, [3]float64{50, -1, 2}, 2.3669},
+		{[3]float64{50, 2.49, -0.001}, [3]float64{50, -2.49, 0.0011}, 7.2195},
+		{[3]float64{50, 2.5, 0}, [3]float64{73, 25, -18}, 27.1492},
+		{[3]float64{60.2574, -34.0099, 36.2677}, [3]float64{60.4626, -34.1751, 39.4387}, 1.2644},
+		{[3]float64{22.7233, 20.0904, -46.694}, [3]float64{23.0331, 14.973, -42.5619}, 2.0373},
+	}
+	for _, tt := range tests {
+		got := deltaE2000(tt.lab1[0], tt.lab1[1], tt.lab1[2], tt.lab2[0], tt.lab2[1], tt.lab2[2])
+		if math.Abs(got-tt.want) > 0.0001 {
+			t.Errorf("deltaE2000(%v, %v) = %.4f, want %.4f", tt.lab1, tt.lab2, got, tt.want)
+		}
+	}
+
+	if d := DeltaE(color.RGBA{0x33, 0x66, 0x99, 255}, color.RGBA{0x33, 0x66, 0x99, 255}); d != 0 {
+		t.Errorf("DeltaE of a color with itself = %v, want 0", d)
+	}
+}
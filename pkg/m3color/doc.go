@@ -0,0 +1,8 @@
+// Package m3color implements the Material 3 color engine behind the theme
+// generator: HCT colors, Chrome's tonal palettes and the color roles of a
+// light or dark scheme.
+//
+//	palette := m3color.GenerateChromePalette(seed, m3color.TonalSpot)
+//	scheme := m3color.NewScheme(palette, m3color.Dark, 0)
+//	fmt.Println(scheme.Hex("primary"))
+package m3color
@@ -0,0 +1,104 @@
+package m3color
+
+import (
+	"image/color"
+	"math"
+)
+
+// HCT is a color in hue, chroma and tone coordinates.
+//
+// The conversions are a simplified approximation based on HSV rather than
+// the CAM16 model used by Chrome; tone corresponds to HSV value.
+type HCT struct {
+	Hue    float64 // 0-360
+	Chroma float64 // 0-infinity (practical max ~120)
+	Tone   float64 // 0-100 (lightness)
+}
+
+// RGBToHCT converts an sRGB color to HCT (simplified - using basic conversion).
+func RGBToHCT(r, g, b uint8) HCT {
+	// Convert to 0-1 range
+	rf := float64(r) / 255.0
+	gf := float64(g) / 255.0
+	bf := float64(b) / 255.0
+
+	// Convert to HSV first
+	max := math.Max(rf, math.Max(gf, bf))
+	min := math.Min(rf, math.Min(gf, bf))
+	delta := max - min
+
+	var hue float64
+	if delta == 0 {
+		hue = 0
+	} else if max == rf {
+		hue = 60 * (math.Mod((gf-bf)/delta, 6))
+	} else if max == gf {
+		hue = 60 * ((bf-rf)/delta + 2)
+	} else {
+		hue = 60 * ((rf-gf)/delta + 4)
+	}
+
+	if hue < 0 {
+		hue += 360
+	}
+
+	// Approximate chroma and tone mappings
+	saturation := 0.0
+	if max != 0 {
+		saturation = delta / max
+	}
+
+	// Map to Material 3 HCT space
+	chroma := saturation * 120.0 // Scale to Material 3 chroma range
+	tone := max * 100.0          // Lightness/tone
+
+	return HCT{
+		Hue:    hue,
+		Chroma: chroma,
+		Tone:   tone,
+	}
+}
+
+// ToRGB converts the color to sRGB (simplified).
+func (h HCT) ToRGB() color.RGBA {
+	// Simplified conversion - in real Chrome this uses CAM16 and complex math
+	hue := h.Hue
+	saturation := math.Min(h.Chroma/120.0, 1.0)
+	value := h.Tone / 100.0
+
+	c := value * saturation
+	x := c * (1 - math.Abs(math.Mod(hue/60.0, 2)-1))
+	m := value - c
+
+	var r, g, b float64
+
+	switch {
+	case hue < 60:
+		r, g, b = c, x, 0
+	case hue < 120:
+		r, g, b = x, c, 0
+	case hue < 180:
+		r, g, b = 0, c, x
+	case hue < 240:
+		r, g, b = 0, x, c
+	case hue < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+
+	return color.RGBA{
+		R: uint8((r + m) * 255),
+		G: uint8((g + m) * 255),
+		B: uint8((b + m) * 255),
+		A: 255,
+	}
+}
+
+func sanitizeDegreesDouble(degrees float64) float64 {
+	degrees = math.Mod(degrees, 360.0)
+	if degrees < 0 {
+		degrees += 360.0
+	}
+	return degrees
+}
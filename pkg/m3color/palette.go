@@ -0,0 +1,214 @@
+package m3color
+
+import (
+	"image/color"
+	"math"
+	"sort"
+)
+
+// Chrome's exact Material 3 implementation ported from C++
+// Source: ui/color/dynamic_color/palette_factory.cc
+
+// SchemeVariant selects how the palettes are derived from the seed color.
+type SchemeVariant int
+
+const (
+	TonalSpot SchemeVariant = iota
+	Vibrant
+	Neutral
+	Expressive
+)
+
+// ChromePalette holds the tonal palettes derived from a seed: Chrome's six
+// and three more semantic ones.
+type ChromePalette struct {
+	Primary        TonalPalette
+	Secondary      TonalPalette
+	Tertiary       TonalPalette
+	Neutral        TonalPalette
+	NeutralVariant TonalPalette
+	Error          TonalPalette
+	// Success, Warning and Info are fixed semantic colors which, like Error,
+	// are harmonized towards the seed.
+	Success TonalPalette
+	Warning TonalPalette
+	Info    TonalPalette
+}
+
+// NamedPalette pairs a palette with its role family name.
+type NamedPalette struct {
+	Name    string
+	Palette TonalPalette
+}
+
+// Palettes returns the palettes in a stable order, named as in scheme roles.
+func (p ChromePalette) Palettes() []NamedPalette {
+	return []NamedPalette{
+		{"primary", p.Primary},
+		{"secondary", p.Secondary},
+		{"tertiary", p.Tertiary},
+		{"neutral", p.Neutral},
+		{"neutralVariant", p.NeutralVariant},
+		{"error", p.Error},
+		{"success", p.Success},
+		{"warning", p.Warning},
+		{"info", p.Info},
+	}
+}
+
+// transform describes how one palette's hue and chroma follow the seed.
+type transform struct {
+	HueRotation     float64
+	Chroma          float64
+	HuesToRotations []hueValue
+	HuesToChroma    []hueValue
+}
+
+// hueValue pairs a source hue with the rotation or chroma used near it.
+type hueValue struct {
+	Hue, Value float64
+}
+
+// hueTable zips hues and values into a table sorted by hue. Lookups walk it
+// in order, so ties between equally near hues always go the same way.
+func hueTable(hues, values []float64) []hueValue {
+	table := make([]hueValue, len(hues))
+	for i, h := range hues {
+		table[i] = hueValue{Hue: h, Value: values[i]}
+	}
+	sort.SliceStable(table, func(i, j int) bool { return table[i].Hue < table[j].Hue })
+	return table
+}
+
+// paletteConfig holds the transform of each palette for a variant.
+type paletteConfig struct {
+	Primary        transform
+	Secondary      transform
+	Tertiary       transform
+	Neutral        transform
+	NeutralVariant transform
+}
+
+func getRotatedHue(sourceHue float64, huesToRotations []hueValue) float64 {
+	if len(huesToRotations) == 1 {
+		return sanitizeDegreesDouble(sourceHue + huesToRotations[0].Value)
+	}
+
+	return sanitizeDegreesDouble(sourceHue + nearestHueValue(sourceHue, huesToRotations))
+}
+
+func getAdjustedChroma(sourceHue float64, huesToChroma []hueValue) float64 {
+	return nearestHueValue(sourceHue, huesToChroma)
+}
+
+// nearestHueValue returns the value of the table entry whose hue is closest
+// to sourceHue; of two equally close entries the lower hue wins.
+func nearestHueValue(sourceHue float64, table []hueValue) float64 {
+	var best float64
+	minDiff := 360.0
+
+	for _, entry := range table {
+		diff := math.Abs(sourceHue - entry.Hue)
+		if diff < minDiff {
+			minDiff = diff
+			best = entry.Value
+		}
+	}
+
+	return best
+}
+
+func makePalette(hue float64, transform transform) TonalPalette {
+	chroma := transform.Chroma
+
+	if transform.HuesToChroma != nil {
+		chroma = getAdjustedChroma(hue, transform.HuesToChroma)
+	}
+
+	if transform.HuesToRotations != nil {
+		hue = getRotatedHue(hue, transform.HuesToRotations)
+	} else {
+		hue = sanitizeDegreesDouble(hue + transform.HueRotation)
+	}
+
+	return newTonalPalette(hue, chroma)
+}
+
+// GenerateChromePalette derives Chrome's palettes from a seed color, using
+// Chrome's exact Material 3 configurations from palette_factory.cc.
+func GenerateChromePalette(seedColor color.RGBA, variant SchemeVariant) ChromePalette {
+	hct := RGBToHCT(seedColor.R, seedColor.G, seedColor.B)
+	hue := hct.Hue
+
+	var config paletteConfig
+
+	switch variant {
+	case TonalSpot:
+		// Chrome's kTonalSpot: {Chroma(40.0), Chroma(16.0), transform{60.0, 24.0}, Chroma(6.0), Chroma(8.0)}
+		config = paletteConfig{
+			Primary:        transform{Chroma: 40.0},
+			Secondary:      transform{Chroma: 16.0},
+			Tertiary:       transform{HueRotation: 60.0, Chroma: 24.0},
+			Neutral:        transform{Chroma: 6.0},
+			NeutralVariant: transform{Chroma: 8.0},
+		}
+	case Vibrant:
+		// Chrome's kVibrant with hue rotations
+		hues := []float64{0, 41, 61, 101, 131, 181, 251, 301, 360}
+		secondaryRotations := []float64{18, 15, 10, 12, 15, 18, 15, 12, 12}
+		tertiaryRotations := []float64{35, 30, 20, 25, 30, 35, 30, 25, 25}
+
+		secondaryHuesToRotations := hueTable(hues, secondaryRotations)
+		tertiaryHuesToRotations := hueTable(hues, tertiaryRotations)
+
+		config = paletteConfig{
+			Primary:        transform{Chroma: 200.0}, // Very high chroma!
+			Secondary:      transform{Chroma: 24.0, HuesToRotations: secondaryHuesToRotations},
+			Tertiary:       transform{Chroma: 32.0, HuesToRotations: tertiaryHuesToRotations},
+			Neutral:        transform{Chroma: 8.0},
+			NeutralVariant: transform{Chroma: 12.0},
+		}
+	case Neutral:
+		// Chrome's kNeutral
+		hues := []float64{0, 260, 315, 360}
+		chromas := []float64{12.0, 12.0, 20.0, 12.0}
+
+		huesToChroma := hueTable(hues, chromas)
+
+		config = paletteConfig{
+			Primary:        transform{HuesToChroma: huesToChroma},
+			Secondary:      transform{Chroma: 8.0},
+			Tertiary:       transform{Chroma: 16.0},
+			Neutral:        transform{Chroma: 2.0},
+			NeutralVariant: transform{Chroma: 2.0},
+		}
+	case Expressive:
+		// Chrome's kExpressive
+		hues := []float64{0, 21, 51, 121, 151, 191, 271, 321, 360}
+		secondaryRotations := []float64{45, 95, 45, 20, 45, 90, 45, 45, 45}
+		tertiaryRotations := []float64{120, 120, 20, 45, 20, 15, 20, 120, 120}
+
+		secondaryHuesToRotations := hueTable(hues, secondaryRotations)
+		tertiaryHuesToRotations := hueTable(hues, tertiaryRotations)
+
+		config = paletteConfig{
+			Primary:        transform{HueRotation: -90, Chroma: 40.0},
+			Secondary:      transform{Chroma: 24.0, HuesToRotations: secondaryHuesToRotations},
+			Tertiary:       transform{Chroma: 32.0, HuesToRotations: tertiaryHuesToRotations},
+			Neutral:        transform{Chroma: 8.0},
+			NeutralVariant: transform{Chroma: 12.0},
+		}
+	}
+
+	return ChromePalette{
+		Primary:        makePalette(hue, config.Primary),
+		Secondary:      makePalette(hue, config.Secondary),
+		Tertiary:       makePalette(hue, config.Tertiary),
+		Neutral:        makePalette(hue, config.Neutral),
+		NeutralVariant: makePalette(hue, config.NeutralVariant),
+		Error:          semanticPalette(errorDesign, hct),
+		Success:        semanticPalette(successDesign, hct),
+		Warning:        semanticPalette(warningDesign, hct),
+		Info:           semanticPalette(infoDesign, hct),
+	}
+}
@@ -0,0 +1,26 @@
+package m3color
+
+import "testing"
+
+func TestNearestHueValue(t *testing.T) {
+	// Listed out of order: hueTable sorts, so ties do not depend on the
+	// order the table was written in.
+	table := hueTable([]float64{41, 0, 61}, []float64{2, 1, 3})
+	tests := []struct {
+		hue  float64
+		want float64
+	}{
+		{0, 1},
+		{20.4, 1},
+		{20.5, 1}, // exactly between 0 and 41: the lower hue wins
+		{20.6, 2},
+		{51, 2}, // exactly between 41 and 61
+		{51.1, 3},
+		{300, 3},
+	}
+	for _, tt := range tests {
+		if got := nearestHueValue(tt.hue, table); got != tt.want {
+			t.Errorf("nearestHueValue(%v) = %v, want %v", tt.hue, got, tt.want)
+		}
+	}
+}
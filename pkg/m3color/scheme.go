@@ -0,0 +1,271 @@
+package m3color
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// Mode selects the light or dark version of a scheme.
+type Mode int
+
+const (
+	Light Mode = iota
+	Dark
+)
+
+// String returns "light" or "dark".
+func (m Mode) String() string {
+	if m == Dark {
+		return "dark"
+	}
+	return "light"
+}
+
+// ParseMode parses "light" or "dark"; the empty string means light.
+func ParseMode(s string) (Mode, error) {
+	switch strings.ToLower(s) {
+	case "light", "":
+		return Light, nil
+	case "dark":
+		return Dark, nil
+	}
+	return Light, fmt.Errorf("invalid mode %q (valid: light, dark)", s)
+}
+
+// VariantNames lists the values accepted by ParseVariant.
+var VariantNames = []string{"tonal_spot", "vibrant", "expressive", "neutral", "monochrome"}
+
+// ParseVariant parses a variant name; the empty string means tonal_spot.
+func ParseVariant(s string) (SchemeVariant, error) {
+	switch s {
+	case "tonal_spot", "":
+		return TonalSpot, nil
+	case "vibrant":
+		return Vibrant, nil
+	case "expressive":
+		return Expressive, nil
+	case "neutral", "monochrome":
+		return Neutral, nil // Use neutral for monochrome
+	}
+	return TonalSpot, fmt.Errorf("invalid variant %q (valid: %s)", s, strings.Join(VariantNames, ", "))
+}
+
+// schemeRole maps a Material 3 color role to a tone of one of the palettes.
+type schemeRole struct {
+	name    string
+	palette func(ChromePalette) TonalPalette
+	light   int
+	dark    int
+	// foreground roles are drawn on top of other roles; the contrast level
+	// moves them away from (or towards) mid-tone.
+	foreground bool
+}
+
+func primaryPalette(p ChromePalette) TonalPalette        { return p.Primary }
+func secondaryPalette(p ChromePalette) TonalPalette      { return p.Secondary }
+func tertiaryPalette(p ChromePalette) TonalPalette       { return p.Tertiary }
+func errorPalette(p ChromePalette) TonalPalette          { return p.Error }
+func successPalette(p ChromePalette) TonalPalette        { return p.Success }
+func warningPalette(p ChromePalette) TonalPalette        { return p.Warning }
+func infoPalette(p ChromePalette) TonalPalette           { return p.Info }
+func neutralPalette(p ChromePalette) TonalPalette        { return p.Neutral }
+func neutralVariantPalette(p ChromePalette) TonalPalette { return p.NeutralVariant }
+
+// schemeRoles are the roles of a scheme, in output order. base and onBase are
+// Chrome's kColorSysBase/kColorSysOnBase used for the browser frame.
+var schemeRoles = []schemeRole{
+	{"primary", primaryPalette, 40, 80, true},
+	{"onPrimary", primaryPalette, 100, 20, true},
+	{"primaryContainer", primaryPalette, 90, 30, false},
+	{"onPrimaryContainer", primaryPalette, 10, 90, true},
+	{"primaryFixed", primaryPalette, 90, 90, false},
+	{"primaryFixedDim", primaryPalette, 80, 80, false},
+	{"secondary", secondaryPalette, 40, 80, true},
+	{"onSecondary", secondaryPalette, 100, 20, true},
+	{"secondaryContainer", secondaryPalette, 90, 30, false},
+	{"onSecondaryContainer", secondaryPalette, 10, 90, true},
+	{"tertiary", tertiaryPalette, 40, 80, true},
+	{"onTertiary", tertiaryPalette, 100, 20, true},
+	{"tertiaryContainer", tertiaryPalette, 90, 30, false},
+	{"onTertiaryContainer", tertiaryPalette, 10, 90, true},
+	{"error", errorPalette, 40, 80, true},
+	{"onError", errorPalette, 100, 20, true},
+	{"errorContainer", errorPalette, 90, 30, false},
+	{"onErrorContainer", errorPalette, 10, 90, true},
+	{"success", successPalette, 40, 80, true},
+	{"onSuccess", successPalette, 100, 20, true},
+	{"successContainer", successPalette, 90, 30, false},
+	{"onSuccessContainer", successPalette, 10, 90, true},
+	{"warning", warningPalette, 40, 80, true},
+	{"onWarning", warningPalette, 100, 20, true},
+	{"warningContainer", warningPalette, 90, 30, false},
+	{"onWarningContainer", warningPalette, 10, 90, true},
+	{"info", infoPalette, 40, 80, true},
+	{"onInfo", infoPalette, 100, 20, true},
+	{"infoContainer", infoPalette, 90, 30, false},
+	{"onInfoContainer", infoPalette, 10, 90, true},
+	{"base", neutralPalette, 98, 6, false},
+	{"onBase", neutralPalette, 10, 90, true},
+	{"surface", neutralPalette, 99, 10, false},
+	{"onSurface", neutralPalette, 10, 90, true},
+	{"surfaceVariant", neutralVariantPalette, 90, 30, false},
+	{"onSurfaceVariant", neutralVariantPalette, 30, 80, true},
+	{"outline", neutralVariantPalette, 50, 60, true},
+	{"outlineVariant", neutralVariantPalette, 80, 30, false},
+}
+
+// SemanticRoles are the roles of the error, success, warning and info colors.
+var SemanticRoles = []string{
+	"error", "onError", "errorContainer", "onErrorContainer",
+	"success", "onSuccess", "successContainer", "onSuccessContainer",
+	"warning", "onWarning", "warningContainer", "onWarningContainer",
+	"info", "onInfo", "infoContainer", "onInfoContainer",
+}
+
+// Scheme is a set of resolved color roles for one mode and contrast level.
+type Scheme struct {
+	Mode     Mode
+	Contrast float64
+	Palette  ChromePalette
+	// Custom holds the palettes of the colors added with AddCustomColor, named
+	// after their base role.
+	Custom []NamedPalette
+	roles  []string
+	colors map[string]color.RGBA
+}
+
+// NewScheme resolves every role of palette for mode. contrast ranges from -1
+// (reduced) through 0 (standard) to 1 (high).
+func NewScheme(palette ChromePalette, mode Mode, contrast float64) *Scheme {
+	contrast = math.Max(-1, math.Min(1, contrast))
+	s := &Scheme{
+		Mode:     mode,
+		Contrast: contrast,
+		Palette:  palette,
+		colors:   make(map[string]color.RGBA, len(schemeRoles)),
+	}
+	for _, r := range schemeRoles {
+		s.add(r)
+	}
+	return s
+}
+
+func (s *Scheme) add(r schemeRole) {
+	tone := float64(r.light)
+	if s.Mode == Dark {
+		tone = float64(r.dark)
+	}
+	if r.foreground {
+		tone = adjustToneForContrast(tone, s.Contrast)
+	}
+	s.roles = append(s.roles, r.name)
+	s.colors[r.name] = r.palette(s.Palette).Get(tone)
+}
+
+// CustomColor is an extra named color, such as a brand color, that gets a
+// role group of its own.
+type CustomColor struct {
+	Name  string
+	Value color.RGBA
+	// Harmonize shifts the color's hue towards the seed, as for the
+	// semantic colors.
+	Harmonize bool
+}
+
+// NewCustomColor returns a custom color, checking that name turns into valid
+// role names.
+func NewCustomColor(name string, value color.RGBA, harmonize bool) (CustomColor, error) {
+	c := CustomColor{Name: name, Value: value, Harmonize: harmonize}
+	if _, err := c.Roles(); err != nil {
+		return CustomColor{}, err
+	}
+	return c, nil
+}
+
+// validCustomName matches names that turn into CSS-safe role names.
+var validCustomName = regexp.MustCompile(`^[a-z][a-z0-9]*([-_][a-z0-9]+)*$`)
+
+// Roles returns the names of the color's role group, in camelCase: "diff-added"
+// has diffAdded, onDiffAdded, diffAddedContainer and onDiffAddedContainer. It
+// fails for names that are not lowercase words joined by - or _.
+func (c CustomColor) Roles() ([]string, error) {
+	if !validCustomName.MatchString(c.Name) {
+		return nil, fmt.Errorf("invalid custom color name %q: use lowercase letters, digits, - and _", c.Name)
+	}
+	var name strings.Builder
+	for i, word := range strings.FieldsFunc(c.Name, func(r rune) bool { return r == '-' || r == '_' }) {
+		if i > 0 {
+			word = strings.ToUpper(word[:1]) + word[1:]
+		}
+		name.WriteString(word)
+	}
+	base := name.String()
+	on := "on" + strings.ToUpper(base[:1]) + base[1:]
+	return []string{base, on, base + "Container", on + "Container"}, nil
+}
+
+// AddCustomColor resolves the role group of c, with the same tones as the
+// error roles, and appends it to the scheme. seed is the color c is
+// harmonized towards.
+func (s *Scheme) AddCustomColor(c CustomColor, seed color.RGBA) error {
+	roles, err := c.Roles()
+	if err != nil {
+		return err
+	}
+	for _, role := range roles {
+		if _, ok := s.colors[role]; ok {
+			return fmt.Errorf("custom color %q clashes with the %s role", c.Name, role)
+		}
+	}
+
+	hct := RGBToHCT(c.Value.R, c.Value.G, c.Value.B)
+	if c.Harmonize {
+		hct.Hue = harmonizeHue(hct.Hue, RGBToHCT(seed.R, seed.G, seed.B))
+	}
+	palette := newTonalPalette(hct.Hue, hct.Chroma)
+	s.Custom = append(s.Custom, NamedPalette{Name: roles[0], Palette: palette})
+
+	paletteOf := func(ChromePalette) TonalPalette { return palette }
+	s.add(schemeRole{roles[0], paletteOf, 40, 80, true})
+	s.add(schemeRole{roles[1], paletteOf, 100, 20, true})
+	s.add(schemeRole{roles[2], paletteOf, 90, 30, false})
+	s.add(schemeRole{roles[3], paletteOf, 10, 90, true})
+	return nil
+}
+
+// adjustToneForContrast approximates Material's contrast levels: positive
+// levels push a foreground tone towards black or white, whichever it is
+// already closer to, and negative levels pull it towards mid-tone.
+func adjustToneForContrast(tone, contrast float64) float64 {
+	target := 50.0
+	if contrast > 0 {
+		target = 0
+		if tone >= 50 {
+			target = 100
+		}
+	}
+	return tone + math.Abs(contrast)/2*(target-tone)
+}
+
+// Roles returns the role names in a stable order.
+func (s *Scheme) Roles() []string {
+	return s.roles
+}
+
+// Color returns the color of a role; unknown roles are transparent black.
+func (s *Scheme) Color(role string) color.RGBA {
+	return s.colors[role]
+}
+
+// Hex returns the color of a role as #rrggbb.
+func (s *Scheme) Hex(role string) string {
+	return Hex(s.colors[role])
+}
+
+// Hex formats c as #rrggbb.
+func Hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
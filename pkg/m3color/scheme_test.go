@@ -0,0 +1,108 @@
+package m3color
+
+import (
+	"image/color"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCustomColorRoles(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string // nil means the name is rejected
+	}{
+		{"link", []string{"link", "onLink", "linkContainer", "onLinkContainer"}},
+		{"diff-added", []string{"diffAdded", "onDiffAdded", "diffAddedContainer", "onDiffAddedContainer"}},
+		{"brand_2", []string{"brand2", "onBrand2", "brand2Container", "onBrand2Container"}},
+		{"", nil},
+		{"-", nil},
+		{"_-_", nil},
+		{"-link", nil},
+		{"link-", nil},
+		{"diff--added", nil},
+		{"Link", nil},
+		{"2link", nil},
+		{"link color", nil},
+	}
+	for _, tt := range tests {
+		got, err := CustomColor{Name: tt.name}.Roles()
+		if tt.want == nil {
+			if err == nil {
+				t.Errorf("Roles() for %q = %q, want an error", tt.name, got)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Roles() for %q = %q, %v; want %q", tt.name, got, err, tt.want)
+		}
+	}
+
+	if _, err := NewCustomColor("-", color.RGBA{}, false); err == nil {
+		t.Error(`NewCustomColor("-") succeeded`)
+	}
+}
+
+func TestAddCustomColor(t *testing.T) {
+	seed := color.RGBA{0x00, 0x00, 0xff, 255}
+	brand := color.RGBA{0xe0, 0x40, 0x10, 255}
+	newScheme := func() *Scheme {
+		return NewScheme(GenerateChromePalette(seed, TonalSpot), Light, 0)
+	}
+
+	tests := []struct {
+		name string
+		err  string
+	}{
+		{"primary", "clashes with the primary role"},
+		{"on-primary", "clashes with the onPrimary role"},
+		{"surface-variant", "clashes with the surfaceVariant role"},
+		{"success", "clashes with the success role"},
+		{"-", "invalid custom color name"},
+	}
+	for _, tt := range tests {
+		err := newScheme().AddCustomColor(CustomColor{Name: tt.name, Value: brand}, seed)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("AddCustomColor(%q) = %v, want an error containing %q", tt.name, err, tt.err)
+		}
+	}
+
+	s := newScheme()
+	if err := s.AddCustomColor(CustomColor{Name: "brand", Value: brand}, seed); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddCustomColor(CustomColor{Name: "brand", Value: brand}, seed); err == nil {
+		t.Error("adding the same custom color twice succeeded")
+	}
+	roles := s.Roles()
+	if got := roles[len(roles)-4:]; !reflect.DeepEqual(got, []string{"brand", "onBrand", "brandContainer", "onBrandContainer"}) {
+		t.Errorf("last roles = %q, want the brand group", got)
+	}
+}
+
+func TestAddCustomColorHarmonize(t *testing.T) {
+	seed := color.RGBA{0x00, 0x00, 0xff, 255}
+	brand := color.RGBA{0xe0, 0x40, 0x10, 255}
+	brandHCT := RGBToHCT(brand.R, brand.G, brand.B)
+
+	for _, harmonize := range []bool{false, true} {
+		s := NewScheme(GenerateChromePalette(seed, TonalSpot), Light, 0)
+		if err := s.AddCustomColor(CustomColor{Name: "brand", Value: brand, Harmonize: harmonize}, seed); err != nil {
+			t.Fatal(err)
+		}
+		want := brandHCT.Hue
+		if harmonize {
+			want = harmonizeHue(brandHCT.Hue, RGBToHCT(seed.R, seed.G, seed.B))
+		}
+		palette := s.Custom[0].Palette
+		if palette.Hue() != want || palette.Chroma() != brandHCT.Chroma {
+			t.Errorf("Harmonize %v: palette hue %v chroma %v, want hue %v chroma %v", harmonize, palette.Hue(), palette.Chroma(), want, brandHCT.Chroma)
+		}
+		if harmonize && palette.Hue() == brandHCT.Hue {
+			t.Error("harmonizing towards a distant seed left the hue unchanged")
+		}
+		if got, want := s.Color("brand"), palette.Tone(40); got != want {
+			t.Errorf("brand role = %v, want tone 40 %v", got, want)
+		}
+	}
+}
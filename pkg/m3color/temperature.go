@@ -0,0 +1,202 @@
+package m3color
+
+import (
+	"image/color"
+	"math"
+	"sort"
+	"sync"
+)
+
+// TemperatureCache finds colors that relate to an input color by warmth, as
+// Material's TemperatureCache does: analogous colors spaced evenly in
+// temperature rather than in hue, and the complement of the input's
+// temperature. Colors are computed on first use and then reused.
+type TemperatureCache struct {
+	input color.RGBA
+
+	once       sync.Once
+	byHue      []tempColor // 361 entries, one per degree of hue
+	byTemp     []tempColor // byHue plus the input, coldest first
+	inputTemp  float64
+	complement color.RGBA
+}
+
+// tempColor is a color with its HCT coordinates and raw temperature.
+type tempColor struct {
+	rgb  color.RGBA
+	hct  HCT
+	temp float64
+}
+
+// NewTemperatureCache returns a cache for input.
+func NewTemperatureCache(input color.RGBA) *TemperatureCache {
+	input.A = 255
+	return &TemperatureCache{input: input}
+}
+
+func (tc *TemperatureCache) init() {
+	tc.once.Do(func() {
+		in := newTempColor(tc.input)
+		tc.inputTemp = in.temp
+
+		// Every hue at the input's chroma and tone.
+		tc.byHue = make([]tempColor, 361)
+		for hue := range tc.byHue {
+			tc.byHue[hue] = newTempColor(HCT{Hue: float64(hue), Chroma: in.hct.Chroma, Tone: in.hct.Tone}.ToRGB())
+		}
+
+		tc.byTemp = append(append([]tempColor(nil), tc.byHue...), in)
+		sort.SliceStable(tc.byTemp, func(i, j int) bool { return tc.byTemp[i].temp < tc.byTemp[j].temp })
+
+		tc.complement = tc.findComplement(in)
+	})
+}
+
+func newTempColor(c color.RGBA) tempColor {
+	return tempColor{rgb: c, hct: RGBToHCT(c.R, c.G, c.B), temp: rawTemperature(c)}
+}
+
+// coldest and warmest are the extremes of the input's hue circle.
+func (tc *TemperatureCache) coldest() tempColor { return tc.byTemp[0] }
+func (tc *TemperatureCache) warmest() tempColor { return tc.byTemp[len(tc.byTemp)-1] }
+
+// relativeTemperature places temp between the coldest (0) and warmest (1)
+// colors of the hue circle.
+func (tc *TemperatureCache) relativeTemperature(temp float64) float64 {
+	tempRange := tc.warmest().temp - tc.coldest().temp
+	if tempRange == 0 {
+		return 0.5
+	}
+	return (temp - tc.coldest().temp) / tempRange
+}
+
+// hueColor returns the hue circle entry nearest hue.
+func (tc *TemperatureCache) hueColor(hue float64) tempColor {
+	return tc.byHue[int(math.Round(sanitizeDegreesDouble(hue)))]
+}
+
+// Complement returns the color whose relative temperature mirrors the
+// input's, found on the arc between the coldest and warmest hues that does
+// not contain the input.
+func (tc *TemperatureCache) Complement() color.RGBA {
+	tc.init()
+	return tc.complement
+}
+
+func (tc *TemperatureCache) findComplement(in tempColor) color.RGBA {
+	coldestHue, warmestHue := tc.coldest().hct.Hue, tc.warmest().hct.Hue
+
+	startHue, endHue := coldestHue, warmestHue
+	if isBetween(in.hct.Hue, coldestHue, warmestHue) {
+		startHue, endHue = warmestHue, coldestHue
+	}
+
+	answer := tc.hueColor(in.hct.Hue)
+	want := 1 - tc.relativeTemperature(in.temp)
+	smallestError := math.Inf(1)
+	for addend := 0.0; addend <= 360; addend++ {
+		hue := sanitizeDegreesDouble(startHue + addend)
+		if !isBetween(hue, startHue, endHue) {
+			continue
+		}
+		candidate := tc.hueColor(hue)
+		if err := math.Abs(want - tc.relativeTemperature(candidate.temp)); err < smallestError {
+			smallestError, answer = err, candidate
+		}
+	}
+	return answer.rgb
+}
+
+// Analogous returns count colors centred on the input, taken from the hue
+// circle divided into divisions steps of equal temperature change. Material
+// uses 5 colors and 12 divisions.
+func (tc *TemperatureCache) Analogous(count, divisions int) []color.RGBA {
+	if count < 1 || divisions < 1 {
+		return nil
+	}
+	tc.init()
+
+	startHue := int(math.Round(RGBToHCT(tc.input.R, tc.input.G, tc.input.B).Hue)) % 360
+	start := tc.byHue[startHue]
+	hueAt := func(addend int) tempColor { return tc.byHue[(startHue+addend)%360] }
+
+	// The total temperature change around the circle, split into equal steps.
+	totalDelta := 0.0
+	last := tc.relativeTemperature(start.temp)
+	for i := 0; i < 360; i++ {
+		temp := tc.relativeTemperature(hueAt(i).temp)
+		totalDelta += math.Abs(temp - last)
+		last = temp
+	}
+	step := totalDelta / float64(divisions)
+
+	all := []tempColor{start}
+	delta := 0.0
+	last = tc.relativeTemperature(start.temp)
+	for addend := 1; len(all) < divisions; addend++ {
+		c := hueAt(addend)
+		temp := tc.relativeTemperature(c.temp)
+		delta += math.Abs(temp - last)
+		last = temp
+
+		// A large jump in temperature can satisfy several steps at once.
+		for len(all) < divisions && delta >= float64(len(all))*step {
+			all = append(all, c)
+		}
+		if addend >= 360 {
+			for len(all) < divisions {
+				all = append(all, c)
+			}
+		}
+	}
+
+	at := func(i int) color.RGBA {
+		return all[((i%len(all))+len(all))%len(all)].rgb
+	}
+	ccw := (count - 1) / 2
+	answers := make([]color.RGBA, 0, count)
+	for i := ccw; i >= 1; i-- {
+		answers = append(answers, at(-i))
+	}
+	answers = append(answers, tc.input)
+	for i := 1; i <= count-ccw-1; i++ {
+		answers = append(answers, at(i))
+	}
+	return answers
+}
+
+// isBetween reports whether angle lies on the clockwise arc from a to b.
+func isBetween(angle, a, b float64) bool {
+	if a < b {
+		return a <= angle && angle <= b
+	}
+	return a <= angle || angle <= b
+}
+
+// rawTemperature is Ou, Woodcock and Wright's warmth measure on the color's
+// L*a*b* hue and chroma: warm oranges score high, cool blues low.
+func rawTemperature(c color.RGBA) float64 {
+	_, a, b := labFromRGB(c)
+	hue := sanitizeDegreesDouble(math.Atan2(b, a) * 180 / math.Pi)
+	chroma := math.Hypot(a, b)
+	return -0.5 + 0.02*math.Pow(chroma, 1.07)*math.Cos(sanitizeDegreesDouble(hue-50)*math.Pi/180)
+}
+
+// labFromRGB converts an sRGB color to CIE L*a*b* under D65.
+func labFromRGB(c color.RGBA) (l, a, b float64) {
+	r, g, bl := linearize(c.R), linearize(c.G), linearize(c.B)
+
+	x := (0.41233895*r + 0.35762064*g + 0.18051042*bl) / 0.95047
+	y := 0.2126*r + 0.7152*g + 0.0722*bl
+	z := (0.01932141*r + 0.11916382*g + 0.95034478*bl) / 1.08883
+
+	f := func(t float64) float64 {
+		const e, k = 216.0 / 24389.0, 24389.0 / 27.0
+		if t > e {
+			return math.Cbrt(t)
+		}
+		return (k*t + 16) / 116
+	}
+	fx, fy, fz := f(x), f(y), f(z)
+	return 116*fy - 16, 500 * (fx - fy), 200 * (fy - fz)
+}
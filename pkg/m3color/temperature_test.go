@@ -0,0 +1,123 @@
+package m3color
+
+import (
+	"image/color"
+	"math"
+	"testing"
+)
+
+var (
+	blue  = color.RGBA{0, 0, 255, 255}
+	red   = color.RGBA{255, 0, 0, 255}
+	green = color.RGBA{0, 255, 0, 255}
+	white = color.RGBA{255, 255, 255, 255}
+	black = color.RGBA{0, 0, 0, 255}
+)
+
+func TestRawTemperature(t *testing.T) {
+	// Material's TemperatureCache test values.
+	tests := []struct {
+		c    color.RGBA
+		want float64
+	}{
+		{blue, -1.393},
+		{red, 2.351},
+		{green, -0.267},
+		{white, -0.5},
+		{black, -0.5},
+	}
+	for _, tt := range tests {
+		if got := rawTemperature(tt.c); math.Abs(got-tt.want) > 0.001 {
+			t.Errorf("rawTemperature(%v) = %.4f, want %.3f", tt.c, got, tt.want)
+		}
+	}
+}
+
+// The complement and analogous colors below are recorded from this package.
+// They differ from Material's because HCT here is derived from HSV rather
+// than CAM16, but the temperature relationships are the same.
+
+func TestComplement(t *testing.T) {
+	tests := []struct {
+		input, want color.RGBA
+	}{
+		{blue, color.RGBA{0xff, 0x50, 0x00, 255}},
+		{red, color.RGBA{0x00, 0xa1, 0xff, 255}},
+		{green, color.RGBA{0xff, 0x00, 0x8c, 255}},
+		{white, white},
+		{black, black},
+	}
+	for _, tt := range tests {
+		tc := NewTemperatureCache(tt.input)
+		got := tc.Complement()
+		if got != tt.want {
+			t.Errorf("Complement(%v) = %v, want %v", tt.input, got, tt.want)
+		}
+		if tc.Complement() != got {
+			t.Errorf("Complement(%v) changed on the second call", tt.input)
+		}
+	}
+
+	// The complement of a cold color is warm and vice versa, at the input's
+	// chroma and tone.
+	for _, input := range []color.RGBA{blue, red, {0x33, 0x66, 0x99, 255}} {
+		c := NewTemperatureCache(input).Complement()
+		in, out := RGBToHCT(input.R, input.G, input.B), RGBToHCT(c.R, c.G, c.B)
+		if math.Abs(in.Chroma-out.Chroma) > 1 || math.Abs(in.Tone-out.Tone) > 1 {
+			t.Errorf("Complement(%v) = %+v, want chroma and tone of %+v", input, out, in)
+		}
+		if (rawTemperature(input) < 0) == (rawTemperature(c) < rawTemperature(input)) {
+			t.Errorf("Complement(%v) = %v does not mirror its temperature", input, c)
+		}
+	}
+}
+
+func TestAnalogous(t *testing.T) {
+	hex := func(cs []color.RGBA) []string {
+		s := make([]string, len(cs))
+		for i, c := range cs {
+			s[i] = Hex(c)
+		}
+		return s
+	}
+	tests := []struct {
+		input           color.RGBA
+		count, division int
+		want            []string
+	}{
+		{blue, 5, 12, []string{"#00ff55", "#00ffbf", "#0000ff", "#ae00ff", "#ff00ff"}},
+		{red, 5, 12, []string{"#ff0083", "#ff0048", "#ff0000", "#ff9400", "#f2ff00"}},
+		{green, 5, 12, []string{"#faff00", "#aaff00", "#00ff00", "#00ff8c", "#00f6ff"}},
+		{white, 3, 12, []string{"#ffffff", "#ffffff", "#ffffff"}},
+		{blue, 1, 12, []string{"#0000ff"}},
+		{blue, 0, 12, []string{}},
+		{blue, 5, 0, []string{}},
+	}
+	for _, tt := range tests {
+		got := hex(NewTemperatureCache(tt.input).Analogous(tt.count, tt.division))
+		if len(got) != len(tt.want) {
+			t.Errorf("Analogous(%v, %d, %d) = %q, want %q", tt.input, tt.count, tt.division, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("Analogous(%v, %d, %d) = %q, want %q", tt.input, tt.count, tt.division, got, tt.want)
+				break
+			}
+		}
+	}
+
+	// The input is in the middle, and the others keep its chroma and tone.
+	input := color.RGBA{0x33, 0x66, 0x99, 255}
+	in := RGBToHCT(input.R, input.G, input.B)
+	colors := NewTemperatureCache(input).Analogous(5, 12)
+	if colors[2] != input {
+		t.Errorf("Analogous()[2] = %v, want the input %v", colors[2], input)
+	}
+	for _, c := range colors {
+		h := RGBToHCT(c.R, c.G, c.B)
+		if math.Abs(in.Chroma-h.Chroma) > 1 || math.Abs(in.Tone-h.Tone) > 1 {
+			t.Errorf("analogous color %+v does not keep the chroma and tone of %+v", h, in)
+		}
+	}
+}
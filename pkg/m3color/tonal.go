@@ -0,0 +1,136 @@
+package m3color
+
+import (
+	"image/color"
+	"math"
+	"sync"
+)
+
+// ToneStops are the standard Material 3 tones, from black to white. They
+// cover every tone the scheme roles use, including the surface container
+// tones between 4 and 24.
+var ToneStops = []float64{
+	0, 4, 5, 6, 10, 12, 17, 20, 22, 24, 25, 30, 35, 40,
+	50, 60, 70, 80, 87, 90, 92, 94, 95, 96, 98, 99, 100,
+}
+
+// TonalPalette is a range of tones sharing one hue and chroma. Computed tones
+// are cached; copies of a palette share the cache.
+type TonalPalette struct {
+	hue      float64
+	chroma   float64
+	keyColor HCT
+	cache    *toneCache
+}
+
+type toneCache struct {
+	mu    sync.Mutex
+	tones map[float64]color.RGBA
+}
+
+func newTonalPalette(hue, chroma float64) TonalPalette {
+	return TonalPalette{
+		hue:      hue,
+		chroma:   chroma,
+		keyColor: findKeyColor(hue, chroma),
+		cache:    &toneCache{tones: make(map[float64]color.RGBA)},
+	}
+}
+
+// findKeyColor finds the displayable color that best represents hue and
+// chroma, as Material's KeyColor does: tone 50 if the chroma is reachable
+// there, otherwise the tone nearest 50 with the highest reachable chroma.
+// Requested chromas above what sRGB can show (Vibrant asks for 200) are
+// reduced to that maximum.
+func findKeyColor(hue, chroma float64) HCT {
+	// 8-bit rounding alone moves chroma by up to about one unit.
+	const epsilon = 1.0
+	bestTone, bestChroma := 50.0, maxChroma(hue, 50)
+	if bestChroma < chroma-epsilon {
+		for tone := 1.0; tone < 100; tone++ {
+			c := maxChroma(hue, tone)
+			if c > bestChroma+epsilon ||
+				(math.Abs(c-bestChroma) <= epsilon && math.Abs(tone-50) < math.Abs(bestTone-50)) {
+				bestTone, bestChroma = tone, c
+			}
+		}
+	}
+
+	rgb := HCT{Hue: hue, Chroma: math.Min(chroma, bestChroma), Tone: bestTone}.ToRGB()
+	key := RGBToHCT(rgb.R, rgb.G, rgb.B)
+	if key.Chroma < epsilon {
+		// Greys have no hue of their own; keep the palette's.
+		key.Hue = hue
+	}
+	return key
+}
+
+// maxChroma returns the highest chroma that survives a round trip through
+// sRGB at hue and tone.
+func maxChroma(hue, tone float64) float64 {
+	rgb := HCT{Hue: hue, Chroma: math.MaxFloat64, Tone: tone}.ToRGB()
+	return RGBToHCT(rgb.R, rgb.G, rgb.B).Chroma
+}
+
+// Hue returns the palette's hue in degrees.
+func (tp TonalPalette) Hue() float64 {
+	return tp.hue
+}
+
+// Chroma returns the palette's chroma.
+func (tp TonalPalette) Chroma() float64 {
+	return tp.chroma
+}
+
+// KeyColor returns the displayable color that represents the palette. Its
+// chroma may be lower than Chroma when the requested chroma is out of gamut.
+func (tp TonalPalette) KeyColor() HCT {
+	return tp.keyColor
+}
+
+// Tone returns the palette's color at tone (0 black - 100 white).
+func (tp TonalPalette) Tone(tone int) color.RGBA {
+	return tp.Get(float64(tone))
+}
+
+// Get returns the palette's color at a possibly fractional tone, clamped to
+// 0-100.
+func (tp TonalPalette) Get(tone float64) color.RGBA {
+	tone = math.Max(0, math.Min(100, tone))
+	if tp.cache == nil {
+		return tp.compute(tone)
+	}
+
+	tp.cache.mu.Lock()
+	defer tp.cache.mu.Unlock()
+	if c, ok := tp.cache.tones[tone]; ok {
+		return c
+	}
+	c := tp.compute(tone)
+	tp.cache.tones[tone] = c
+	return c
+}
+
+func (tp TonalPalette) compute(tone float64) color.RGBA {
+	hct := HCT{
+		Hue:    tp.hue,
+		Chroma: tp.chroma,
+		Tone:   tone,
+	}
+	return hct.ToRGB()
+}
+
+// PaletteTone is one tone of a palette and its color.
+type PaletteTone struct {
+	Tone  float64
+	Color color.RGBA
+}
+
+// Stops returns the palette's colors at the ToneStops.
+func (tp TonalPalette) Stops() []PaletteTone {
+	stops := make([]PaletteTone, len(ToneStops))
+	for i, tone := range ToneStops {
+		stops[i] = PaletteTone{Tone: tone, Color: tp.Get(tone)}
+	}
+	return stops
+}
@@ -0,0 +1,118 @@
+package m3color
+
+import (
+	"image/color"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestToneStops(t *testing.T) {
+	if !sort.Float64sAreSorted(ToneStops) || ToneStops[0] != 0 || ToneStops[len(ToneStops)-1] != 100 {
+		t.Errorf("ToneStops = %v, want sorted from 0 to 100", ToneStops)
+	}
+
+	tp := newTonalPalette(240, 48)
+	stops := tp.Stops()
+	if len(stops) != len(ToneStops) {
+		t.Fatalf("Stops() has %d entries, want %d", len(stops), len(ToneStops))
+	}
+	for i, s := range stops {
+		if s.Tone != ToneStops[i] || s.Color != tp.compute(s.Tone) {
+			t.Errorf("Stops()[%d] = %v, want tone %v colored %v", i, s, ToneStops[i], tp.compute(ToneStops[i]))
+		}
+	}
+	if stops[0].Color != (color.RGBA{0, 0, 0, 255}) {
+		t.Errorf("tone 0 is %v, want black", stops[0].Color)
+	}
+}
+
+func TestTonalPaletteCache(t *testing.T) {
+	tp := newTonalPalette(30, 60)
+	tests := []struct {
+		tone, cachedAs float64
+	}{
+		{40, 40},
+		{40.5, 40.5}, // fractional tones are their own entries
+		{-10, 0},     // clamped before caching
+		{130, 100},
+	}
+	for _, tt := range tests {
+		if got, want := tp.Get(tt.tone), tp.compute(tt.cachedAs); got != want {
+			t.Errorf("Get(%v) = %v, want %v", tt.tone, got, want)
+		}
+		if _, ok := tp.cache.tones[tt.cachedAs]; !ok {
+			t.Errorf("Get(%v) did not cache tone %v", tt.tone, tt.cachedAs)
+		}
+	}
+	if len(tp.cache.tones) != len(tests) {
+		t.Errorf("cache holds %d tones, want %d", len(tp.cache.tones), len(tests))
+	}
+
+	// A cached entry is returned as is, and copies share the cache.
+	marker := color.RGBA{1, 2, 3, 255}
+	tp.cache.tones[40] = marker
+	palCopy := tp
+	if got := palCopy.Tone(40); got != marker {
+		t.Errorf("copy's Tone(40) = %v, want the cached %v", got, marker)
+	}
+	palCopy.Get(75)
+	if _, ok := tp.cache.tones[75]; !ok {
+		t.Error("a tone computed through a copy is missing from the original's cache")
+	}
+
+	// The zero palette has no cache and still computes.
+	var zero TonalPalette
+	if got := zero.Get(100); got != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("zero palette Get(100) = %v, want white", got)
+	}
+}
+
+func TestTonalPaletteConcurrentGet(t *testing.T) {
+	tp := newTonalPalette(120, 40)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, tone := range ToneStops {
+				if got, want := tp.Get(tone), tp.compute(tone); got != want {
+					t.Errorf("Get(%v) = %v, want %v", tone, got, want)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestFindKeyColor(t *testing.T) {
+	// 8-bit rounding moves each coordinate by up to about one unit.
+	const epsilon = 1.0
+	tests := []struct {
+		name        string
+		hue, chroma float64
+		want        HCT
+	}{
+		{"reachable at tone 50", 240, 60, HCT{Hue: 240, Chroma: 60, Tone: 50}},
+		{"reachable low chroma", 60, 16, HCT{Hue: 60, Chroma: 16, Tone: 50}},
+		{"at the gamut edge", 240, 120, HCT{Hue: 240, Chroma: 120, Tone: 50}},
+		{"vibrant chroma reduced", 240, 200, HCT{Hue: 240, Chroma: 120, Tone: 50}},
+		{"vibrant chroma reduced, warm hue", 30, 200, HCT{Hue: 30, Chroma: 120, Tone: 50}},
+		{"grey keeps the palette hue", 200, 0, HCT{Hue: 200, Chroma: 0, Tone: 50}},
+	}
+	near := func(a, b float64) bool { return a-b <= epsilon && b-a <= epsilon }
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findKeyColor(tt.hue, tt.chroma)
+			if !near(got.Hue, tt.want.Hue) || !near(got.Chroma, tt.want.Chroma) || !near(got.Tone, tt.want.Tone) {
+				t.Errorf("findKeyColor(%v, %v) = %+v, want %+v ±%v", tt.hue, tt.chroma, got, tt.want, epsilon)
+			}
+			if got.Chroma > maxChroma(tt.hue, got.Tone)+epsilon {
+				t.Errorf("key chroma %v is not displayable at tone %v", got.Chroma, got.Tone)
+			}
+			if kc := newTonalPalette(tt.hue, tt.chroma).KeyColor(); kc != got {
+				t.Errorf("KeyColor() = %+v, want %+v", kc, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"image/color"
+	"log"
+	"math"
+	"os/exec"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+
+	"material-gtk/pkg/m3color"
+)
+
+const appearanceNamespace = "org.freedesktop.appearance"
+
+// modeSystem is the -mode value that follows the desktop's color-scheme
+// preference instead of fixing light or dark.
+const modeSystem = "system"
+
+// readPortalSetting reads one setting through org.freedesktop.portal.Settings.
+func readPortalSetting(conn *dbus.Conn, namespace, key string) (dbus.Variant, error) {
+	obj := conn.Object(portalBusName, portalPath)
+
+	var v dbus.Variant
+	err := obj.Call(portalSettingsIface+".ReadOne", 0, namespace, key).Store(&v)
+	if err == nil {
+		return v, nil
+	}
+
+	// ReadOne appeared in version 2 of the interface; the deprecated Read
+	// wraps the value in an extra variant.
+	if errRead := obj.Call(portalSettingsIface+".Read", 0, namespace, key).Store(&v); errRead != nil {
+		return dbus.Variant{}, fmt.Errorf("failed to read %s %s from the settings portal: %w", namespace, key, err)
+	}
+	if inner, ok := v.Value().(dbus.Variant); ok {
+		v = inner
+	}
+	return v, nil
+}
+
+// colorSchemeMode maps the portal's color-scheme value (0 no preference,
+// 1 prefer dark, 2 prefer light) to a mode.
+func colorSchemeMode(v dbus.Variant) (m3color.Mode, bool) {
+	scheme, ok := v.Value().(uint32)
+	if !ok {
+		return m3color.Light, false
+	}
+	if scheme == 1 {
+		return m3color.Dark, true
+	}
+	return m3color.Light, true
+}
+
+// systemColorScheme returns the mode the desktop prefers, asking the settings
+// portal first and GNOME's gsettings key if the portal is unavailable.
+func systemColorScheme() (m3color.Mode, error) {
+	conn, err := sessionBus()
+	if err == nil {
+		defer conn.Close()
+		if v, err := readPortalSetting(conn, appearanceNamespace, "color-scheme"); err == nil {
+			if mode, ok := colorSchemeMode(v); ok {
+				return mode, nil
+			}
+		}
+	}
+
+	out, err := exec.Command("gsettings", "get", "org.gnome.desktop.interface", "color-scheme").Output()
+	if err != nil {
+		return m3color.Light, fmt.Errorf("cannot determine the system color scheme: settings portal and gsettings unavailable")
+	}
+	if strings.Contains(string(out), "prefer-dark") {
+		return m3color.Dark, nil
+	}
+	return m3color.Light, nil
+}
+
+// accentColor reads the accent color the desktop publishes as
+// org.freedesktop.appearance accent-color, an (r, g, b) triple of doubles in
+// [0, 1]. Values outside that range mean no accent is set.
+func accentColor() (color.RGBA, error) {
+	conn, err := sessionBus()
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("cannot read the accent color: %w", err)
+	}
+	defer conn.Close()
+
+	v, err := readPortalSetting(conn, appearanceNamespace, "accent-color")
+	if err != nil {
+		return color.RGBA{}, err
+	}
+	rgb, ok := v.Value().([]interface{})
+	if !ok || len(rgb) != 3 {
+		return color.RGBA{}, fmt.Errorf("unexpected accent-color value %v", v)
+	}
+
+	var channels [3]uint8
+	for i, c := range rgb {
+		f, ok := c.(float64)
+		if !ok {
+			return color.RGBA{}, fmt.Errorf("unexpected accent-color value %v", v)
+		}
+		if f < 0 || f > 1 {
+			return color.RGBA{}, fmt.Errorf("the desktop has no accent color set")
+		}
+		channels[i] = uint8(math.Round(f * 255))
+	}
+	return color.RGBA{channels[0], channels[1], channels[2], 255}, nil
+}
+
+// followColorScheme applies the theme in the desktop's preferred mode and
+// re-applies it whenever the portal reports a new color-scheme.
+func followColorScheme(ctx context.Context, opts *options) error {
+	conn, err := sessionBus()
+	if err != nil {
+		return fmt.Errorf("cannot follow the system color scheme: %w", err)
+	}
+	defer conn.Close()
+
+	if err := conn.AddMatchSignal(
+		dbus.WithMatchObjectPath(portalPath),
+		dbus.WithMatchInterface(portalSettingsIface),
+		dbus.WithMatchMember("SettingChanged"),
+		dbus.WithMatchArg(0, appearanceNamespace),
+	); err != nil {
+		return fmt.Errorf("failed to subscribe to the settings portal: %w", err)
+	}
+	signals := make(chan *dbus.Signal, 8)
+	conn.Signal(signals)
+
+	v, err := readPortalSetting(conn, appearanceNamespace, "color-scheme")
+	if err != nil {
+		return err
+	}
+	current, ok := colorSchemeMode(v)
+	if !ok {
+		return fmt.Errorf("unexpected color-scheme value %v", v)
+	}
+
+	apply := func(mode m3color.Mode) {
+		fmt.Printf("🌓 System prefers %s mode\n", mode)
+		o := *opts
+		o.mode = mode.String()
+		if err := generate(&o); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+	apply(current)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case sig, ok := <-signals:
+			if !ok {
+				return fmt.Errorf("session bus connection closed")
+			}
+			// SettingChanged(s namespace, s key, v value)
+			if sig.Name != portalSettingsIface+".SettingChanged" || len(sig.Body) != 3 ||
+				sig.Body[0] != appearanceNamespace || sig.Body[1] != "color-scheme" {
+				continue
+			}
+			v, ok := sig.Body[2].(dbus.Variant)
+			if !ok {
+				continue
+			}
+			if mode, ok := colorSchemeMode(v); ok && mode != current {
+				current = mode
+				apply(mode)
+			}
+		}
+	}
+}
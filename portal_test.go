@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	"material-gtk/pkg/m3color"
+)
+
+// fakePortal serves org.freedesktop.portal.Settings values, keyed by
+// "namespace key".
+type fakePortal struct {
+	values map[string]dbus.Variant
+}
+
+func (p *fakePortal) ReadOne(namespace, key string) (dbus.Variant, *dbus.Error) {
+	v, ok := p.values[namespace+" "+key]
+	if !ok {
+		return dbus.Variant{}, dbus.NewError("org.freedesktop.portal.Error.NotFound", []interface{}{"no such setting"})
+	}
+	return v, nil
+}
+
+// fakeLegacyPortal only has the deprecated Read, which wraps the value in a
+// second variant.
+type fakeLegacyPortal struct {
+	values map[string]dbus.Variant
+}
+
+func (p *fakeLegacyPortal) Read(namespace, key string) (dbus.Variant, *dbus.Error) {
+	v, ok := p.values[namespace+" "+key]
+	if !ok {
+		return dbus.Variant{}, dbus.NewError("org.freedesktop.portal.Error.NotFound", []interface{}{"no such setting"})
+	}
+	return dbus.MakeVariant(v), nil
+}
+
+// startFakePortal exports portal as the settings portal on a private bus and
+// returns the portal's connection, for emitting signals.
+func startFakePortal(t *testing.T, portal interface{}) *dbus.Conn {
+	t.Helper()
+	addr := startTestBus(t)
+	conn := testBusConn(t, addr, portalBusName)
+	if err := conn.Export(portal, portalPath, portalSettingsIface); err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+// accentValue is an accent-color value, marshalled as (ddd).
+func accentValue(r, g, b float64) dbus.Variant {
+	return dbus.MakeVariant(struct{ R, G, B float64 }{r, g, b})
+}
+
+func TestAccentColor(t *testing.T) {
+	const key = appearanceNamespace + " accent-color"
+	tests := []struct {
+		name   string
+		portal interface{}
+		want   color.RGBA
+		err    string
+	}{
+		{
+			name:   "ReadOne",
+			portal: &fakePortal{map[string]dbus.Variant{key: accentValue(0.2, 0.4, 0.6)}},
+			want:   color.RGBA{51, 102, 153, 255},
+		},
+		{
+			name:   "Read fallback",
+			portal: &fakeLegacyPortal{map[string]dbus.Variant{key: accentValue(1, 0.5, 0)}},
+			want:   color.RGBA{255, 128, 0, 255},
+		},
+		{
+			name:   "no accent set",
+			portal: &fakePortal{map[string]dbus.Variant{key: accentValue(-1, -1, -1)}},
+			err:    "no accent color",
+		},
+		{
+			name:   "wrong type",
+			portal: &fakePortal{map[string]dbus.Variant{key: dbus.MakeVariant("blue")}},
+			err:    "unexpected accent-color value",
+		},
+		{
+			name:   "not published",
+			portal: &fakePortal{},
+			err:    "failed to read",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			startFakePortal(t, tt.portal)
+			got, err := accentColor()
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("accentColor() = %v, %v; want an error containing %q", got, err, tt.err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("accentColor() = %v, %v; want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSystemColorScheme(t *testing.T) {
+	const key = appearanceNamespace + " color-scheme"
+	tests := []struct {
+		name   string
+		portal interface{}
+		want   m3color.Mode
+	}{
+		{"prefer dark", &fakePortal{map[string]dbus.Variant{key: dbus.MakeVariant(uint32(1))}}, m3color.Dark},
+		{"prefer light", &fakePortal{map[string]dbus.Variant{key: dbus.MakeVariant(uint32(2))}}, m3color.Light},
+		{"no preference", &fakePortal{map[string]dbus.Variant{key: dbus.MakeVariant(uint32(0))}}, m3color.Light},
+		{"Read fallback", &fakeLegacyPortal{map[string]dbus.Variant{key: dbus.MakeVariant(uint32(1))}}, m3color.Dark},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			startFakePortal(t, tt.portal)
+			got, err := systemColorScheme()
+			if err != nil || got != tt.want {
+				t.Errorf("systemColorScheme() = %v, %v; want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFollowColorScheme(t *testing.T) {
+	const key = appearanceNamespace + " color-scheme"
+	conn := startFakePortal(t, &fakePortal{map[string]dbus.Variant{key: dbus.MakeVariant(uint32(1))}})
+	out := filepath.Join(t.TempDir(), "scheme.json")
+	opts := loadTestOptions(t, "", "-rgb", "#336699", "-mode", modeSystem, "-format", "json", "-output", out)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- followColorScheme(ctx, &opts) }()
+	defer func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("followColorScheme: %v", err)
+		}
+	}()
+
+	waitForMode := func(want string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		var doc schemeDocument
+		for time.Now().Before(deadline) {
+			if data, err := os.ReadFile(out); err == nil && json.Unmarshal(data, &doc) == nil && doc.Mode == want {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("mode is %q, want %s", doc.Mode, want)
+	}
+	settingChanged := func(namespace, name string, value interface{}) {
+		t.Helper()
+		if err := conn.Emit(portalPath, portalSettingsIface+".SettingChanged", namespace, name, dbus.MakeVariant(value)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	waitForMode("dark")
+
+	// Other settings and an unchanged scheme leave the theme alone.
+	if err := os.Remove(out); err != nil {
+		t.Fatal(err)
+	}
+	settingChanged(appearanceNamespace, "accent-color", struct{ R, G, B float64 }{1, 0, 0})
+	settingChanged("org.gnome.desktop.interface", "color-scheme", uint32(2))
+	settingChanged(appearanceNamespace, "color-scheme", uint32(1))
+	time.Sleep(200 * time.Millisecond)
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Errorf("theme regenerated for a signal that does not change the scheme")
+	}
+
+	settingChanged(appearanceNamespace, "color-scheme", uint32(2))
+	waitForMode("light")
+	settingChanged(appearanceNamespace, "color-scheme", uint32(1))
+	waitForMode("dark")
+}
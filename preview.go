@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+
+	"material-gtk/pkg/m3color"
+)
+
+// Preview layout, in pixels.
+const (
+	previewWidth   = 960
+	windowHeight   = 560
+	previewMargin  = 24
+	swatchHeight   = 28
+	swatchSpacing  = 6
+	tabStripHeight = 44
+	toolbarHeight  = 48
+)
+
+// previewPNG renders the theme's preview image as PNG.
+func previewPNG(spec themeSpec) ([]byte, error) {
+	scheme, err := spec.scheme()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, renderPreview(scheme)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// renderPreview draws a mock browser window using the roles the GTK CSS
+// maps to each widget, followed by one swatch strip per palette. Text is
+// drawn as bars in the text color, so no fonts are needed.
+func renderPreview(scheme *m3color.Scheme) *image.RGBA {
+	palettes := append(scheme.Palette.Palettes(), scheme.Custom...)
+	height := previewMargin + windowHeight + previewMargin +
+		len(palettes)*(swatchHeight+swatchSpacing) + previewMargin
+	img := image.NewRGBA(image.Rect(0, 0, previewWidth, height))
+	role := scheme.Color
+
+	fill(img, img.Bounds(), role("surfaceVariant"))
+
+	win := image.Rect(previewMargin, previewMargin, previewWidth-previewMargin, previewMargin+windowHeight)
+	roundRect(img, win, 12, role("surface"))
+
+	// Tab strip: the frame uses the neutral base, like headerbar.titlebar.
+	strip := image.Rect(win.Min.X, win.Min.Y, win.Max.X, win.Min.Y+tabStripHeight)
+	roundRect(img, strip, 12, role("base"))
+	fill(img, image.Rect(strip.Min.X, strip.Max.Y-12, strip.Max.X, strip.Max.Y), role("base"))
+	tabX := strip.Min.X + 16
+	for i, title := range []int{120, 90, 140} {
+		tab := image.Rect(tabX, strip.Min.Y+8, tabX+200, strip.Max.Y)
+		bg, fg := role("surfaceVariant"), role("onSurfaceVariant") // notebook tab
+		if i == 0 {
+			bg, fg = role("primaryContainer"), role("onPrimaryContainer") // notebook tab:checked
+		}
+		roundRect(img, tab, 8, bg)
+		textBar(img, tab.Min.X+14, tab.Min.Y+14, title, fg)
+		tabX += 208
+	}
+
+	// Toolbar with navigation buttons and the omnibox (an entry).
+	toolbar := image.Rect(win.Min.X, strip.Max.Y, win.Max.X, strip.Max.Y+toolbarHeight)
+	fill(img, toolbar, role("base"))
+	for i := 0; i < 3; i++ {
+		x := toolbar.Min.X + 12 + i*40
+		roundRect(img, image.Rect(x, toolbar.Min.Y+8, x+32, toolbar.Max.Y-8), 16, role("surfaceVariant"))
+		roundRect(img, image.Rect(x+10, toolbar.Min.Y+20, x+22, toolbar.Max.Y-20), 2, role("onBase"))
+	}
+	omnibox := image.Rect(toolbar.Min.X+140, toolbar.Min.Y+6, toolbar.Max.X-16, toolbar.Max.Y-6)
+	roundRect(img, omnibox, 18, role("primary")) // entry:focus border
+	roundRect(img, omnibox.Inset(2), 16, role("surfaceVariant"))
+	textBar(img, omnibox.Min.X+20, omnibox.Min.Y+15, 260, role("onSurface"))
+
+	// Page content with text, a selection and buttons in each state.
+	scrollbarWidth := 14
+	content := image.Rect(win.Min.X, toolbar.Max.Y, win.Max.X-scrollbarWidth, win.Max.Y-12)
+	y := content.Min.Y + 32
+	x := content.Min.X + 40
+	for _, w := range []int{420, 560, 500, 380} {
+		textBar(img, x, y, w, role("onSurface"))
+		y += 26
+	}
+	selection := image.Rect(x-4, y-6, x+324, y+18)
+	fill(img, selection, role("primaryContainer"))
+	textBar(img, x, y, 320, role("onPrimaryContainer"))
+	y += 26
+	for _, w := range []int{480, 300} {
+		textBar(img, x, y, w, role("onSurfaceVariant"))
+		y += 26
+	}
+
+	y += 20
+	buttons := []struct{ bg, fg, border color.RGBA }{
+		{role("base"), role("onBase"), role("primary")},                                  // button
+		{role("primaryContainer"), role("onPrimaryContainer"), role("primaryContainer")}, // button:hover
+		{role("primary"), role("onPrimary"), role("primary")},                            // button:active
+	}
+	for _, b := range buttons {
+		r := image.Rect(x, y, x+140, y+40)
+		roundRect(img, r, 8, b.border)
+		roundRect(img, r.Inset(1), 7, b.bg)
+		textBar(img, r.Min.X+30, r.Min.Y+16, 80, b.fg)
+		x += 160
+	}
+
+	// Semantic colors as chips.
+	x = content.Min.X + 40
+	y += 64
+	for _, chip := range [][2]string{
+		{"errorContainer", "onErrorContainer"},
+		{"successContainer", "onSuccessContainer"},
+		{"warningContainer", "onWarningContainer"},
+		{"infoContainer", "onInfoContainer"},
+	} {
+		r := image.Rect(x, y, x+120, y+32)
+		roundRect(img, r, 16, role(chip[0]))
+		textBar(img, r.Min.X+20, r.Min.Y+12, 80, role(chip[1]))
+		x += 136
+	}
+
+	// Scrollbar.
+	track := image.Rect(content.Max.X, toolbar.Max.Y, win.Max.X, win.Max.Y-12)
+	fill(img, track, role("surface"))
+	roundRect(img, image.Rect(track.Min.X+3, track.Min.Y+20, track.Max.X-3, track.Min.Y+140), 4, role("outlineVariant"))
+
+	// Palette swatch strips, one tone stop per cell.
+	y = win.Max.Y + previewMargin
+	for _, p := range palettes {
+		stops := p.Palette.Stops()
+		cellWidth := float64(previewWidth-2*previewMargin) / float64(len(stops))
+		for i, stop := range stops {
+			x0 := previewMargin + int(float64(i)*cellWidth)
+			x1 := previewMargin + int(float64(i+1)*cellWidth)
+			fill(img, image.Rect(x0, y, x1, y+swatchHeight), stop.Color)
+		}
+		y += swatchHeight + swatchSpacing
+	}
+	return img
+}
+
+func fill(img draw.Image, r image.Rectangle, c color.RGBA) {
+	draw.Draw(img, r, image.NewUniform(c), image.Point{}, draw.Src)
+}
+
+// textBar stands in for a line of text of the given width.
+func textBar(img draw.Image, x, y, width int, c color.RGBA) {
+	roundRect(img, image.Rect(x, y, x+width, y+10), 5, c)
+}
+
+// roundRect fills r with c, rounding the corners by radius.
+func roundRect(img draw.Image, r image.Rectangle, radius int, c color.RGBA) {
+	draw.DrawMask(img, r, image.NewUniform(c), image.Point{}, roundedMask{r, radius}, r.Min, draw.Over)
+}
+
+// roundedMask is an alpha mask of a rectangle with rounded corners.
+type roundedMask struct {
+	r      image.Rectangle
+	radius int
+}
+
+func (m roundedMask) ColorModel() color.Model { return color.AlphaModel }
+
+func (m roundedMask) Bounds() image.Rectangle { return m.r }
+
+func (m roundedMask) At(x, y int) color.Color {
+	if !(image.Point{x, y}).In(m.r) {
+		return color.Transparent
+	}
+	rad := min(m.radius, m.r.Dx()/2, m.r.Dy()/2)
+	// Distance from the nearest corner circle's centre, if in a corner.
+	cx, cy := x, y
+	switch {
+	case x < m.r.Min.X+rad:
+		cx = m.r.Min.X + rad
+	case x >= m.r.Max.X-rad:
+		cx = m.r.Max.X - rad - 1
+	}
+	switch {
+	case y < m.r.Min.Y+rad:
+		cy = m.r.Min.Y + rad
+	case y >= m.r.Max.Y-rad:
+		cy = m.r.Max.Y - rad - 1
+	}
+	dx, dy := x-cx, y-cy
+	if dx*dx+dy*dy > rad*rad {
+		return color.Transparent
+	}
+	return color.Opaque
+}
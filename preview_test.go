@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"material-gtk/pkg/m3color"
+)
+
+func TestPreviewPNG(t *testing.T) {
+	opts := loadTestOptions(t, "", "-rgb", "28,32,39", "-custom", "link=#1a73e8")
+	spec, err := opts.spec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := previewPNG(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decoding the preview: %v", err)
+	}
+	scheme, err := spec.scheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// One strip per palette, the custom color's last.
+	palettes := append(scheme.Palette.Palettes(), scheme.Custom...)
+	if last := palettes[len(palettes)-1].Name; last != "link" {
+		t.Fatalf("last palette is %q, want link", last)
+	}
+	wantHeight := previewMargin + windowHeight + previewMargin + len(palettes)*(swatchHeight+swatchSpacing) + previewMargin
+	if b := img.Bounds(); b.Dx() != previewWidth || b.Dy() != wantHeight {
+		t.Fatalf("preview is %dx%d, want %dx%d", b.Dx(), b.Dy(), previewWidth, wantHeight)
+	}
+
+	at := func(x, y int) color.RGBA {
+		r, g, b, a := img.At(x, y).RGBA()
+		return color.RGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), uint8(a >> 8)}
+	}
+	// The backdrop and the window body.
+	if got, want := at(2, 2), scheme.Color("surfaceVariant"); got != want {
+		t.Errorf("backdrop = %s, want surfaceVariant %s", m3color.Hex(got), m3color.Hex(want))
+	}
+	if got, want := at(previewWidth/2, previewMargin+windowHeight-30), scheme.Color("surface"); got != want {
+		t.Errorf("window body = %s, want surface %s", m3color.Hex(got), m3color.Hex(want))
+	}
+
+	// The middle of the first, a middle and the last cell of each strip.
+	y := previewMargin + windowHeight + previewMargin + swatchHeight/2
+	for _, p := range palettes {
+		stops := p.Palette.Stops()
+		cellWidth := float64(previewWidth-2*previewMargin) / float64(len(stops))
+		for _, i := range []int{0, len(stops) / 2, len(stops) - 1} {
+			x := previewMargin + int((float64(i)+0.5)*cellWidth)
+			if got := at(x, y); got != stops[i].Color {
+				t.Errorf("%s tone %g at (%d,%d) = %s, want %s", p.Name, stops[i].Tone, x, y, m3color.Hex(got), m3color.Hex(stops[i].Color))
+			}
+		}
+		y += swatchHeight + swatchSpacing
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"image/color"
+
+	"material-gtk/pkg/m3color"
+)
+
+// reportTemplate is the self-contained HTML palette report: no scripts,
+// fonts or other external resources.
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Material 3 palette report – {{.Seed.Hex}} {{.Variant}}</title>
+<style>
+  body { font: 14px/1.4 system-ui, sans-serif; margin: 2rem; color: #1b1b1f; background: #fafafa; }
+  h1, h2 { font-weight: 600; }
+  table { border-collapse: collapse; margin-bottom: 2rem; }
+  th, td { padding: 4px 10px; text-align: left; border-bottom: 1px solid #e0e0e0; }
+  code { font: 12px ui-monospace, monospace; }
+  .swatch { display: inline-block; width: 2.5em; height: 1.4em; vertical-align: middle; border: 1px solid #0002; border-radius: 4px; margin-right: 6px; }
+  .palette { display: flex; margin-bottom: 6px; }
+  .palette div { flex: 1; height: 3.2em; font: 10px ui-monospace, monospace; padding: 2px; box-sizing: border-box; }
+  .name { width: 9em; flex: none !important; font: 13px system-ui, sans-serif !important; align-self: center; }
+  .fail { color: #b3261e; font-weight: 600; }
+  .pass { color: #146c2e; }
+</style>
+</head>
+<body>
+<h1>Material 3 palette report</h1>
+<table>
+  <tr><th>Seed</th><td><span class="swatch" style="background: {{.Seed.Hex}}"></span><code>{{.Seed.Hex}}</code> RGB({{.Seed.RGB}}) <code>{{.Seed.HCT}}</code></td></tr>
+  <tr><th>Variant</th><td>{{.Variant}}</td></tr>
+  <tr><th>Contrast</th><td>{{.Contrast}}</td></tr>
+{{with .Generated}}  <tr><th>Generated</th><td>{{.}}</td></tr>
+{{end}}</table>
+
+<h2>Tonal palettes</h2>
+{{range .Palettes}}<div class="palette"><div class="name">{{.Name}}</div>{{range .Tones}}<div style="background: {{.Hex}}; color: {{.Label}}" title="{{.Hex}}">{{.Tone}}<br>{{.Hex}}</div>{{end}}</div>
+{{end}}
+<h2>Scheme roles</h2>
+<table>
+  <tr><th>Role</th><th>Light</th><th>Dark</th></tr>
+{{range .Roles}}  <tr><td>{{.Name}}</td>{{range .Modes}}<td><span class="swatch" style="background: {{.Hex}}"></span><code>{{.Hex}}</code> <code>{{.HCT}}</code></td>{{end}}</tr>
+{{end}}</table>
+
+<h2>Contrast of paired roles</h2>
+<p>WCAG 2.x AA needs 4.5:1 for body text; APCA Lc 60 is the usual minimum.</p>
+<table>
+  <tr><th>Background</th><th>Foreground</th><th>Light WCAG</th><th>Light APCA</th><th>Dark WCAG</th><th>Dark APCA</th></tr>
+{{range .Pairs}}  <tr><td>{{.Background}}</td><td>{{.Foreground}}</td>{{range .Modes}}<td class="{{if .Pass}}pass{{else}}fail{{end}}">{{printf "%.2f" .WCAG}}:1</td><td>{{printf "%.1f" .APCA}}</td>{{end}}</tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+type reportColor struct {
+	Hex, RGB, HCT string
+}
+
+func newReportColor(c color.RGBA) reportColor {
+	hct := m3color.RGBToHCT(c.R, c.G, c.B)
+	return reportColor{
+		Hex: colorToHex(c),
+		RGB: fmt.Sprintf("%d,%d,%d", c.R, c.G, c.B),
+		HCT: fmt.Sprintf("H%.0f C%.0f T%.0f", hct.Hue, hct.Chroma, hct.Tone),
+	}
+}
+
+type reportTone struct {
+	Tone  float64
+	Hex   string
+	Label string // text color readable on the tone
+}
+
+type reportPalette struct {
+	Name  string
+	Tones []reportTone
+}
+
+type reportRole struct {
+	Name  string
+	Modes [2]reportColor // light, dark
+}
+
+type reportContrast struct {
+	WCAG, APCA float64
+	Pass       bool
+}
+
+type reportPair struct {
+	Background, Foreground string
+	Modes                  [2]reportContrast // light, dark
+}
+
+type reportData struct {
+	Seed      reportColor
+	Variant   string
+	Contrast  float64
+	Generated string
+	Palettes  []reportPalette
+	Roles     []reportRole
+	Pairs     []reportPair
+}
+
+// htmlReport renders the HTML palette report. It always covers both modes,
+// whatever spec.Mode is.
+func htmlReport(spec themeSpec) ([]byte, error) {
+	var schemes [2]*m3color.Scheme
+	for i, mode := range []m3color.Mode{m3color.Light, m3color.Dark} {
+		s := spec
+		s.Mode = mode
+		scheme, err := s.scheme()
+		if err != nil {
+			return nil, err
+		}
+		schemes[i] = scheme
+	}
+	light := schemes[0]
+	generated, err := spec.generated()
+	if err != nil {
+		return nil, err
+	}
+
+	data := reportData{
+		Seed:      newReportColor(spec.Seed),
+		Variant:   spec.Variant,
+		Contrast:  light.Contrast,
+		Generated: generated,
+	}
+
+	for _, p := range append(light.Palette.Palettes(), light.Custom...) {
+		rp := reportPalette{Name: p.Name}
+		for _, stop := range p.Palette.Stops() {
+			label := "#ffffff"
+			if stop.Tone >= 60 {
+				label = "#000000"
+			}
+			rp.Tones = append(rp.Tones, reportTone{Tone: stop.Tone, Hex: colorToHex(stop.Color), Label: label})
+		}
+		data.Palettes = append(data.Palettes, rp)
+	}
+
+	for _, role := range light.Roles() {
+		r := reportRole{Name: role}
+		for i, scheme := range schemes {
+			r.Modes[i] = newReportColor(scheme.Color(role))
+		}
+		data.Roles = append(data.Roles, r)
+	}
+
+	for _, pair := range onRolePairs(light) {
+		p := reportPair{Background: pair[0], Foreground: pair[1]}
+		for i, scheme := range schemes {
+			bg, fg := scheme.Color(pair[0]), scheme.Color(pair[1])
+			wcag := m3color.ContrastRatio(fg, bg)
+			p.Modes[i] = reportContrast{WCAG: wcag, APCA: m3color.APCAContrast(fg, bg), Pass: wcag >= 4.5}
+		}
+		data.Pairs = append(data.Pairs, p)
+	}
+
+	var buf bytes.Buffer
+	if err := reportTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
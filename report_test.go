@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"material-gtk/pkg/m3color"
+)
+
+func TestHTMLReport(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+	opts := loadTestOptions(t, "", "-rgb", "28,32,39", "-custom", "link=#1a73e8")
+	spec, err := opts.spec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := htmlReport(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := string(out)
+
+	for _, want := range []string{
+		"<title>Material 3 palette report – #1c2027 tonal_spot</title>",
+		"RGB(28,32,39)",
+		"<td>Tue Nov 14 22:13:20 UTC 2023</td>",
+		`<div class="name">link</div>`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("report lacks %q", want)
+		}
+	}
+
+	// One contrast row per paired role, with both modes' ratios and a
+	// class that agrees with the 4.5:1 threshold.
+	var schemes [2]*m3color.Scheme
+	for i, mode := range []m3color.Mode{m3color.Light, m3color.Dark} {
+		s := spec
+		s.Mode = mode
+		if schemes[i], err = s.scheme(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pairs := onRolePairs(schemes[0])
+	row := regexp.MustCompile(`<tr><td>(\w+)</td><td>(\w+)</td>` +
+		`<td class="(pass|fail)">([\d.]+):1</td><td>(-?[\d.]+)</td>` +
+		`<td class="(pass|fail)">([\d.]+):1</td><td>(-?[\d.]+)</td></tr>`)
+	rows := row.FindAllStringSubmatch(html, -1)
+	if len(rows) != len(pairs) {
+		t.Fatalf("%d contrast rows, want %d", len(rows), len(pairs))
+	}
+	for i, m := range rows {
+		if m[1] != pairs[i][0] || m[2] != pairs[i][1] {
+			t.Errorf("row %d is %s/%s, want %s/%s", i, m[1], m[2], pairs[i][0], pairs[i][1])
+			continue
+		}
+		for j, scheme := range schemes {
+			class, ratio := m[3+3*j], m[4+3*j]
+			bg, fg := scheme.Color(pairs[i][0]), scheme.Color(pairs[i][1])
+			wcag := m3color.ContrastRatio(fg, bg)
+			if want := fmt.Sprintf("%.2f", wcag); ratio != want {
+				t.Errorf("%s/%s mode %d ratio %s, want %s", m[1], m[2], j, ratio, want)
+			}
+			if got, _ := strconv.ParseFloat(ratio, 64); (class == "pass") != (wcag >= 4.5) {
+				t.Errorf("%s/%s mode %d: %.2f:1 marked %s", m[1], m[2], j, got, class)
+			}
+		}
+	}
+}
+
+func TestReportTemplateEscaping(t *testing.T) {
+	data := reportData{
+		Seed:      reportColor{Hex: `#000"><script>x</script>`},
+		Variant:   "<b>bold</b>",
+		Generated: "a & b",
+		Palettes:  []reportPalette{{Name: "<img src=x>"}},
+		Roles:     []reportRole{{Name: "</td></tr>"}},
+	}
+	var buf bytes.Buffer
+	if err := reportTemplate.Execute(&buf, data); err != nil {
+		t.Fatal(err)
+	}
+	html := buf.String()
+	for _, raw := range []string{"<script>", "<b>", "<img", "</td></tr></td>", "a & b"} {
+		if strings.Contains(html, raw) {
+			t.Errorf("report contains unescaped %q", raw)
+		}
+	}
+	for _, want := range []string{"&lt;b&gt;bold&lt;/b&gt;", "&lt;img src=x&gt;", "a &amp; b"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("report lacks escaped %q", want)
+		}
+	}
+	// CSS contexts reject anything that isn't a plain value.
+	if strings.Contains(html, `background: #000"`) {
+		t.Error("seed hex was not sanitized in the style attribute")
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// renderAll renders every output target and the installed theme files.
+func renderAll(t *testing.T, spec themeSpec) map[string][]byte {
+	t.Helper()
+	rendered := make(map[string][]byte)
+	outputs := make(map[string]string)
+	for _, target := range outputTargetNames() {
+		content, err := outputTargets[target](spec)
+		if err != nil {
+			t.Fatalf("rendering %s: %v", target, err)
+		}
+		rendered[target] = content
+		outputs[target] = "/tmp/theme." + target
+	}
+	css, err := generateGTKTheme(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := themeFiles("Material", css, spec, outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		rendered[f.Path] = f.Content
+	}
+	return rendered
+}
+
+// setClock makes generated files stamped with t for the rest of the test.
+func setClock(t *testing.T, at time.Time) {
+	t.Helper()
+	saved := now
+	now = func() time.Time { return at }
+	t.Cleanup(func() { now = saved })
+}
+
+func TestReproducibleOutputs(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "")
+	opts := loadTestOptions(t, "", "-rgb", "#336699", "-reproducible", "-custom", "link=#1a73e8:harmonize")
+	spec, err := opts.spec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stamped := spec
+	stamped.Reproducible = false
+
+	// Two renders a day apart: a time stamp that slipped into a
+	// reproducible file would differ between them.
+	var renders, stampedRenders [2]map[string][]byte
+	for i, at := range []time.Time{time.Unix(1700000000, 0), time.Unix(1700086400, 0)} {
+		setClock(t, at)
+		renders[i] = renderAll(t, spec)
+		stampedRenders[i] = renderAll(t, stamped)
+	}
+
+	if len(renders[0]) < len(outputTargets)+4 {
+		t.Errorf("rendered only %d files", len(renders[0]))
+	}
+	for name, content := range renders[0] {
+		if !bytes.Equal(content, renders[1][name]) {
+			t.Errorf("%s differs between two reproducible renders", name)
+		}
+	}
+	// Without -reproducible the stamp follows the clock, so the comparison
+	// above would have caught it.
+	for _, name := range []string{"gtk-3.0/gtk.css", metadataFile} {
+		if bytes.Equal(stampedRenders[0][name], stampedRenders[1][name]) {
+			t.Errorf("%s does not change with the clock without -reproducible", name)
+		}
+	}
+}
+
+func TestSourceDateEpoch(t *testing.T) {
+	const want = "Tue Nov 14 22:13:20 UTC 2023"
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+	for _, reproducible := range []bool{false, true} {
+		got, err := themeSpec{Reproducible: reproducible}.generated()
+		if err != nil || got != want {
+			t.Errorf("generated() with Reproducible %v = %q, %v; want %q", reproducible, got, err, want)
+		}
+	}
+
+	opts := loadTestOptions(t, "", "-rgb", "#336699")
+	spec, err := opts.spec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	css, err := generateGTKTheme(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(css, want) {
+		t.Errorf("generated CSS does not carry the SOURCE_DATE_EPOCH stamp %q", want)
+	}
+
+	// Without it, the clock stamps files unless they are reproducible.
+	t.Setenv("SOURCE_DATE_EPOCH", "")
+	setClock(t, time.Unix(1700000000, 0))
+	if got, _ := (themeSpec{}).generated(); got != time.Unix(1700000000, 0).Format(generatedTimeFormat) {
+		t.Errorf("generated() without SOURCE_DATE_EPOCH = %q", got)
+	}
+	if got, _ := (themeSpec{Reproducible: true}).generated(); got != "" {
+		t.Errorf("generated() for a reproducible spec = %q, want none", got)
+	}
+
+	t.Setenv("SOURCE_DATE_EPOCH", "yesterday")
+	if _, err := (themeSpec{}).generated(); err == nil {
+		t.Error("generated() accepted an invalid SOURCE_DATE_EPOCH")
+	}
+}
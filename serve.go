@@ -0,0 +1,306 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// runServe implements the serve subcommand: a local HTTP API for generating
+// schemes and themes.
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	var opts options
+	opts.register(fs)
+	listen := fs.String("listen", "127.0.0.1:8765", "Loopback address to listen on")
+	socket := fs.String("socket", "", "Listen on this Unix socket instead of TCP")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s serve [options]\n\nOptions:\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if _, err := opts.load(fs); err != nil {
+		return err
+	}
+
+	ln, err := listenLocal(*listen, *socket)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Handler:           newServer(opts).routes(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Printf("🌐 Serving on %s\n", ln.Addr())
+	if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// listenLocal listens on a Unix socket, or on a TCP address that must be a
+// loopback one: POST /apply changes the user's desktop and has no
+// authentication.
+func listenLocal(addr, socket string) (net.Listener, error) {
+	if socket != "" {
+		// A socket left behind by an earlier run would make Listen fail.
+		if info, err := os.Lstat(socket); err == nil && info.Mode()&os.ModeSocket != 0 {
+			os.Remove(socket)
+		}
+		ln, err := net.Listen("unix", socket)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.Chmod(socket, 0600); err != nil {
+			ln.Close()
+			return nil, err
+		}
+		return ln, nil
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid listen address %q: %w", addr, err)
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return nil, fmt.Errorf("refusing to listen on non-loopback address %q", addr)
+	}
+	return net.Listen("tcp", addr)
+}
+
+type server struct {
+	opts options // defaults for parameters a request leaves out
+
+	mu sync.Mutex // serialises installs and theme switches
+}
+
+func newServer(opts options) *server {
+	return &server{opts: opts}
+}
+
+func (s *server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /scheme", s.handleScheme)
+	mux.HandleFunc("GET /theme/gtk3.css", s.handleGTK3)
+	mux.HandleFunc("POST /apply", s.handleApply)
+	return localOnly(mux)
+}
+
+// localOnly rejects requests a web page could have made on the user's behalf:
+// a Host that is not a loopback name (DNS rebinding), an Origin other than
+// the server itself (cross-site requests), and POSTs that are not JSON, which
+// a page cannot send cross-origin without a CORS preflight.
+func localOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isLoopbackHost(r.Host) {
+			writeError(w, http.StatusForbidden, fmt.Errorf("host %q is not a loopback address", r.Host))
+			return
+		}
+		if origin := r.Header.Get("Origin"); origin != "" {
+			u, err := url.Parse(origin)
+			if err != nil || u.Host != r.Host {
+				writeError(w, http.StatusForbidden, fmt.Errorf("cross-origin request from %q", origin))
+				return
+			}
+		}
+		if r.Method == http.MethodPost {
+			mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if err != nil || mediaType != "application/json" {
+				writeError(w, http.StatusUnsupportedMediaType, errors.New("POST bodies must be application/json"))
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isLoopbackHost reports whether a Host header names localhost or a loopback
+// IP, with or without a port.
+func isLoopbackHost(hostport string) bool {
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// themeParams are the parameters accepted by every endpoint: query parameters
+// for GET and a JSON body for POST.
+type themeParams struct {
+	Seed     string   `json:"seed"`
+	Variant  string   `json:"variant"`
+	Mode     string   `json:"mode"`
+	Contrast *float64 `json:"contrast"`
+}
+
+func (s *server) params(r *http.Request) (themeParams, error) {
+	var p themeParams
+	if r.Method == http.MethodPost {
+		// An empty body keeps every server default.
+		if err := json.NewDecoder(io.LimitReader(r.Body, 1<<16)).Decode(&p); err != nil && err != io.EOF {
+			return p, fmt.Errorf("invalid JSON body: %w", err)
+		}
+		return p, nil
+	}
+
+	q := r.URL.Query()
+	p.Seed, p.Variant, p.Mode = q.Get("seed"), q.Get("variant"), q.Get("mode")
+	if c := q.Get("contrast"); c != "" {
+		v, err := strconv.ParseFloat(c, 64)
+		if err != nil {
+			return p, fmt.Errorf("invalid contrast %q", c)
+		}
+		p.Contrast = &v
+	}
+	return p, nil
+}
+
+// requestOptions overlays the request parameters on the server defaults.
+func (s *server) requestOptions(r *http.Request) (options, error) {
+	o := s.opts
+	p, err := s.params(r)
+	if err != nil {
+		return o, err
+	}
+	if p.Seed != "" {
+		o.seed, o.seedFrom = p.Seed, ""
+	}
+	if p.Variant != "" {
+		o.variant = p.Variant
+	}
+	if p.Mode != "" {
+		o.mode = p.Mode
+	}
+	if p.Contrast != nil {
+		o.contrast = *p.Contrast
+	}
+	if o.seed == "" && o.seedFrom == "" {
+		return o, errors.New(`seed is required, e.g. ?seed=%231c2027 or {"seed": "#1c2027"}`)
+	}
+	return o, nil
+}
+
+func (s *server) handleScheme(w http.ResponseWriter, r *http.Request) {
+	o, err := s.requestOptions(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	spec, err := o.spec()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	doc, err := newSchemeDocument(spec)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, doc)
+}
+
+func (s *server) handleGTK3(w http.ResponseWriter, r *http.Request) {
+	o, err := s.requestOptions(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	spec, err := o.spec()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	css, err := generateGTKTheme(spec)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/css; charset=utf-8")
+	fmt.Fprint(w, css)
+}
+
+func (s *server) handleApply(w http.ResponseWriter, r *http.Request) {
+	o, err := s.requestOptions(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	spec, err := o.spec()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Apply exactly the resolved spec, so a -seed-from source or system mode
+	// is not re-read between validation and install.
+	o.seed, o.seedFrom, o.mode = colorToHex(spec.Seed), "", spec.Mode.String()
+	o.apply, o.dryRun = true, false
+
+	s.mu.Lock()
+	err = generate(&o)
+	s.mu.Unlock()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{
+			"applied": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	doc, err := newSchemeDocument(spec)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"applied": true,
+		"theme":   o.themeName,
+		"scheme":  doc,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		log.Printf("Warning: failed to write response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// serveTestRequest sends a request to a server built from opts.
+func serveTestRequest(opts options, method, target, host string, header map[string]string, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r.Host = host
+	for k, v := range header {
+		r.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	newServer(opts).routes().ServeHTTP(w, r)
+	return w
+}
+
+func TestServeLocalOnly(t *testing.T) {
+	opts := loadTestOptions(t, "", "-rgb", "#336699")
+	jsonBody := map[string]string{"Content-Type": "application/json"}
+	tests := []struct {
+		name   string
+		method string
+		target string
+		host   string
+		header map[string]string
+		body   string
+		want   int
+	}{
+		{"loopback IP", "GET", "/scheme", "127.0.0.1:8765", nil, "", http.StatusOK},
+		{"localhost", "GET", "/scheme", "localhost:8765", nil, "", http.StatusOK},
+		{"IPv6 loopback", "GET", "/scheme", "[::1]:8765", nil, "", http.StatusOK},
+		{"same origin", "GET", "/scheme", "127.0.0.1:8765", map[string]string{"Origin": "http://127.0.0.1:8765"}, "", http.StatusOK},
+		{"rebound host name", "GET", "/scheme", "attacker.example:8765", nil, "", http.StatusForbidden},
+		{"foreign origin", "GET", "/scheme", "127.0.0.1:8765", map[string]string{"Origin": "https://attacker.example"}, "", http.StatusForbidden},
+		{"other local origin", "POST", "/apply", "127.0.0.1:8765", map[string]string{"Origin": "http://localhost:3000", "Content-Type": "application/json"}, "{}", http.StatusForbidden},
+		{"form POST", "POST", "/apply", "127.0.0.1:8765", map[string]string{"Content-Type": "application/x-www-form-urlencoded"}, "seed=%23336699", http.StatusUnsupportedMediaType},
+		{"text POST", "POST", "/apply", "127.0.0.1:8765", map[string]string{"Content-Type": "text/plain"}, `{"seed": "#336699"}`, http.StatusUnsupportedMediaType},
+		{"POST without a body type", "POST", "/apply?seed=%23336699", "127.0.0.1:8765", nil, "", http.StatusUnsupportedMediaType},
+		{"invalid JSON", "POST", "/apply", "127.0.0.1:8765", jsonBody, "{", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveTestRequest(opts, tt.method, tt.target, tt.host, tt.header, tt.body)
+			if w.Code != tt.want {
+				t.Errorf("status %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
+
+func TestServeApply(t *testing.T) {
+	themesDir := t.TempDir()
+	opts := loadTestOptions(t, "", "-rgb", "#336699", "-apply-method", "settings-ini", "-themes-dir", themesDir)
+	header := map[string]string{"Content-Type": "application/json; charset=utf-8"}
+
+	t.Run("applied", func(t *testing.T) {
+		configDir := t.TempDir()
+		t.Setenv("XDG_CONFIG_HOME", configDir)
+		w := serveTestRequest(opts, "POST", "/apply", "127.0.0.1:8765", header, `{"mode": "dark"}`)
+		var resp struct {
+			Applied bool   `json:"applied"`
+			Theme   string `json:"theme"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if w.Code != http.StatusOK || !resp.Applied || resp.Theme != defaultThemeName {
+			t.Fatalf("status %d: %s", w.Code, w.Body)
+		}
+		settings, err := os.ReadFile(filepath.Join(configDir, "gtk-3.0", "settings.ini"))
+		if err != nil || !strings.Contains(string(settings), "gtk-theme-name="+defaultThemeName) {
+			t.Errorf("settings.ini = %q, %v", settings, err)
+		}
+	})
+
+	t.Run("applier fails", func(t *testing.T) {
+		// A file where the config directory should be makes settings.ini
+		// unwritable.
+		notADir := filepath.Join(t.TempDir(), "config")
+		if err := os.WriteFile(notADir, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv("XDG_CONFIG_HOME", notADir)
+		w := serveTestRequest(opts, "POST", "/apply", "127.0.0.1:8765", header, `{}`)
+		var resp struct {
+			Applied *bool  `json:"applied"`
+			Error   string `json:"error"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if w.Code != http.StatusInternalServerError || resp.Applied == nil || *resp.Applied || resp.Error == "" {
+			t.Errorf("status %d: %s", w.Code, w.Body)
+		}
+	})
+}
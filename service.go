@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+)
+
+const (
+	serviceBusName = "org.omarchy.MaterialTheme"
+	servicePath    = "/org/omarchy/MaterialTheme"
+	serviceIface   = "org.omarchy.MaterialTheme"
+)
+
+// serviceIntrospection documents the interface for D-Bus clients such as
+// busctl and d-feet.
+var serviceIntrospection = &introspect.Node{
+	Name: servicePath,
+	Interfaces: []introspect.Interface{
+		introspect.IntrospectData,
+		{
+			Name: serviceIface,
+			Methods: []introspect.Method{
+				{
+					Name: "GenerateScheme",
+					Args: []introspect.Arg{
+						{Name: "seed", Type: "s", Direction: "in"},
+						{Name: "variant", Type: "s", Direction: "in"},
+						{Name: "mode", Type: "s", Direction: "in"},
+						{Name: "roles", Type: "a{ss}", Direction: "out"},
+					},
+				},
+				{
+					Name: "Apply",
+					Args: []introspect.Arg{
+						{Name: "seed", Type: "s", Direction: "in"},
+						{Name: "variant", Type: "s", Direction: "in"},
+					},
+				},
+			},
+			Signals: []introspect.Signal{
+				{
+					Name: "ThemeChanged",
+					Args: []introspect.Arg{
+						{Name: "theme", Type: "s"},
+						{Name: "seed", Type: "s"},
+						{Name: "variant", Type: "s"},
+						{Name: "mode", Type: "s"},
+					},
+				},
+			},
+		},
+	},
+}
+
+// themeService implements org.omarchy.MaterialTheme. Empty arguments fall
+// back to the flags and config the service was started with.
+type themeService struct {
+	conn *dbus.Conn
+	opts options
+
+	mu sync.Mutex // serialises installs and theme switches
+}
+
+// exportService publishes the service object on conn. Claiming the bus name
+// is left to the caller.
+func exportService(conn *dbus.Conn, opts options) (*themeService, error) {
+	s := &themeService{conn: conn, opts: opts}
+	if err := conn.Export(s, servicePath, serviceIface); err != nil {
+		return nil, err
+	}
+	if err := conn.Export(introspect.NewIntrospectable(serviceIntrospection), servicePath, "org.freedesktop.DBus.Introspectable"); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func serviceError(err error) *dbus.Error {
+	return dbus.NewError(serviceIface+".Error.Failed", []interface{}{err.Error()})
+}
+
+// options overlays method arguments on the service defaults.
+func (s *themeService) options(seed, variant, mode string) options {
+	o := s.opts
+	if seed != "" {
+		o.seed, o.seedFrom = seed, ""
+	}
+	if variant != "" {
+		o.variant = variant
+	}
+	if mode != "" {
+		o.mode = mode
+	}
+	return o
+}
+
+// GenerateScheme returns the scheme roles as role name → #rrggbb.
+func (s *themeService) GenerateScheme(seed, variant, mode string) (map[string]string, *dbus.Error) {
+	o := s.options(seed, variant, mode)
+	spec, err := o.spec()
+	if err != nil {
+		return nil, serviceError(err)
+	}
+	doc, err := newSchemeDocument(spec)
+	if err != nil {
+		return nil, serviceError(err)
+	}
+	return doc.Roles, nil
+}
+
+// Apply installs and applies the theme, then emits ThemeChanged. If either
+// step fails the error is returned and no signal is emitted.
+func (s *themeService) Apply(seed, variant string) *dbus.Error {
+	o := s.options(seed, variant, "")
+	spec, err := o.spec()
+	if err != nil {
+		return serviceError(err)
+	}
+	o.seed, o.seedFrom, o.mode = colorToHex(spec.Seed), "", spec.Mode.String()
+	o.apply, o.dryRun = true, false
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := generate(&o); err != nil {
+		return serviceError(err)
+	}
+
+	if err := s.conn.Emit(servicePath, serviceIface+".ThemeChanged", o.themeName, o.seed, spec.Variant, o.mode); err != nil {
+		return serviceError(err)
+	}
+	return nil
+}
+
+// runService implements the dbus-service subcommand.
+func runService(args []string) error {
+	fs := flag.NewFlagSet("dbus-service", flag.ExitOnError)
+	var opts options
+	opts.register(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s dbus-service [options]\n\nOptions:\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if _, err := opts.load(fs); err != nil {
+		return err
+	}
+
+	conn, err := sessionBus()
+	if err != nil {
+		return fmt.Errorf("failed to connect to the session bus: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := exportService(conn, opts); err != nil {
+		return err
+	}
+	reply, err := conn.RequestName(serviceBusName, dbus.NameFlagDoNotQueue)
+	if err != nil {
+		return fmt.Errorf("failed to claim %s: %w", serviceBusName, err)
+	}
+	if reply != dbus.RequestNameReplyPrimaryOwner {
+		return fmt.Errorf("%s is already owned by another process", serviceBusName)
+	}
+
+	fmt.Printf("🚌 %s ready on the session bus\n", serviceBusName)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+	return nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// startTestService exports the service on a private bus under its well-known
+// name and returns a client connection subscribed to ThemeChanged.
+func startTestService(t *testing.T, opts options) (dbus.BusObject, chan *dbus.Signal) {
+	t.Helper()
+	addr := startTestBus(t)
+	conn := testBusConn(t, addr)
+	if _, err := exportService(conn, opts); err != nil {
+		t.Fatal(err)
+	}
+	if reply, err := conn.RequestName(serviceBusName, dbus.NameFlagDoNotQueue); err != nil || reply != dbus.RequestNameReplyPrimaryOwner {
+		t.Fatalf("failed to own %s: %v", serviceBusName, err)
+	}
+
+	client := testBusConn(t, addr)
+	if err := client.AddMatchSignal(
+		dbus.WithMatchObjectPath(servicePath),
+		dbus.WithMatchInterface(serviceIface),
+		dbus.WithMatchMember("ThemeChanged"),
+	); err != nil {
+		t.Fatal(err)
+	}
+	signals := make(chan *dbus.Signal, 4)
+	client.Signal(signals)
+	return client.Object(serviceBusName, servicePath), signals
+}
+
+func TestServiceGenerateScheme(t *testing.T) {
+	obj, _ := startTestService(t, loadTestOptions(t, "", "-rgb", "#336699"))
+
+	var roles map[string]string
+	if err := obj.Call(serviceIface+".GenerateScheme", 0, "", "vibrant", "dark").Store(&roles); err != nil {
+		t.Fatalf("GenerateScheme: %v", err)
+	}
+	hex := regexp.MustCompile(`^#[0-9a-f]{6}$`)
+	for _, role := range []string{"primary", "onPrimary", "surface", "error", "successContainer"} {
+		if !hex.MatchString(roles[role]) {
+			t.Errorf("role %s = %q, want #rrggbb", role, roles[role])
+		}
+	}
+
+	var light map[string]string
+	if err := obj.Call(serviceIface+".GenerateScheme", 0, "", "vibrant", "light").Store(&light); err != nil {
+		t.Fatalf("GenerateScheme: %v", err)
+	}
+	if light["surface"] == roles["surface"] {
+		t.Errorf("light and dark surface are both %s", light["surface"])
+	}
+
+	err := obj.Call(serviceIface+".GenerateScheme", 0, "not a color", "", "").Store(&roles)
+	if dbusErr, ok := err.(dbus.Error); !ok || dbusErr.Name != serviceIface+".Error.Failed" {
+		t.Errorf("GenerateScheme with an invalid seed = %v, want %s.Error.Failed", err, serviceIface)
+	}
+}
+
+func TestServiceApply(t *testing.T) {
+	themesDir := t.TempDir()
+	opts := loadTestOptions(t, "", "-rgb", "#336699", "-mode", "dark", "-apply-method", "settings-ini", "-themes-dir", themesDir)
+
+	t.Run("applied", func(t *testing.T) {
+		t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+		obj, signals := startTestService(t, opts)
+
+		if err := obj.Call(serviceIface+".Apply", 0, "#1c2027", "").Err; err != nil {
+			t.Fatalf("Apply: %v", err)
+		}
+		select {
+		case sig := <-signals:
+			want := []interface{}{defaultThemeName, "#1c2027", "tonal_spot", "dark"}
+			if len(sig.Body) != len(want) {
+				t.Fatalf("ThemeChanged%v, want %v", sig.Body, want)
+			}
+			for i := range want {
+				if sig.Body[i] != want[i] {
+					t.Errorf("ThemeChanged%v, want %v", sig.Body, want)
+					break
+				}
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("no ThemeChanged signal")
+		}
+		css, err := os.ReadFile(filepath.Join(themesDir, defaultThemeName, "gtk-3.0", "gtk.css"))
+		if err != nil || !strings.Contains(string(css), "@define-color") {
+			t.Errorf("installed gtk.css: %v", err)
+		}
+	})
+
+	t.Run("applier fails", func(t *testing.T) {
+		// A file where the config directory should be makes settings.ini
+		// unwritable.
+		notADir := filepath.Join(t.TempDir(), "config")
+		if err := os.WriteFile(notADir, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv("XDG_CONFIG_HOME", notADir)
+		obj, signals := startTestService(t, opts)
+
+		err := obj.Call(serviceIface+".Apply", 0, "", "").Err
+		if dbusErr, ok := err.(dbus.Error); !ok || dbusErr.Name != serviceIface+".Error.Failed" {
+			t.Fatalf("Apply = %v, want %s.Error.Failed", err, serviceIface)
+		}
+		select {
+		case sig := <-signals:
+			t.Errorf("ThemeChanged%v emitted after a failed apply", sig.Body)
+		case <-time.After(200 * time.Millisecond):
+		}
+	})
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"unicode"
+
+	"material-gtk/pkg/m3color"
+)
+
+// distinctRoles are role pairs that carry different meanings and so must
+// stay apart, on top of every role and its on-role.
+var distinctRoles = [][2]string{
+	{"primary", "error"},
+	{"error", "success"},
+	{"error", "warning"},
+	{"success", "warning"},
+	{"success", "info"},
+	{"errorContainer", "successContainer"},
+	{"warningContainer", "successContainer"},
+}
+
+// runSimulate implements the simulate subcommand: it shows the scheme roles
+// as seen with color vision deficiencies and flags role pairs that become
+// hard to tell apart.
+func runSimulate(args []string) error {
+	fs := flag.NewFlagSet("simulate", flag.ExitOnError)
+	var opts options
+	opts.register(fs)
+	deficiencies := fs.String("deficiency", "", "Comma-separated deficiencies to simulate (default: all): protanopia, deuteranopia, tritanopia, achromatopsia")
+	minDeltaE := fs.Float64("min-delta-e", 10, "Flag role pairs whose CIEDE2000 difference falls below this")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s simulate [options] [R,G,B]\n\nOptions:\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if _, err := opts.loadWithSeedArg(fs); err != nil {
+		return err
+	}
+	if opts.seed == "" && opts.seedFrom == "" {
+		return fmt.Errorf("simulate needs a seed (R,G,B, -rgb or -seed-from)")
+	}
+
+	sims := m3color.Deficiencies
+	if *deficiencies != "" {
+		sims = nil
+		for _, name := range strings.Split(*deficiencies, ",") {
+			d, err := m3color.ParseDeficiency(strings.TrimSpace(name))
+			if err != nil {
+				return err
+			}
+			sims = append(sims, d)
+		}
+	}
+
+	spec, err := opts.spec()
+	if err != nil {
+		return err
+	}
+	scheme, err := spec.scheme()
+	if err != nil {
+		return err
+	}
+
+	swatches := isTerminal(os.Stdout)
+	printSimulatedRoles(os.Stdout, scheme, sims, swatches)
+	fmt.Println()
+	flagged := printConfusedPairs(os.Stdout, scheme, sims, *minDeltaE)
+	if flagged > 0 {
+		return fmt.Errorf("%d role pairs fall below ΔE %g under simulation", flagged, *minDeltaE)
+	}
+	return nil
+}
+
+// rolePairs returns onRolePairs followed by distinctRoles.
+func rolePairs(scheme *m3color.Scheme) [][2]string {
+	has := make(map[string]bool)
+	for _, role := range scheme.Roles() {
+		has[role] = true
+	}
+
+	pairs := onRolePairs(scheme)
+	for _, p := range distinctRoles {
+		if has[p[0]] && has[p[1]] {
+			pairs = append(pairs, p)
+		}
+	}
+	return pairs
+}
+
+// onRolePairs returns every role paired with its on-role, e.g. primary with
+// onPrimary and base with onBase.
+func onRolePairs(scheme *m3color.Scheme) [][2]string {
+	has := make(map[string]bool)
+	for _, role := range scheme.Roles() {
+		has[role] = true
+	}
+
+	var pairs [][2]string
+	for _, role := range scheme.Roles() {
+		base, ok := strings.CutPrefix(role, "on")
+		if !ok || base == "" || !unicode.IsUpper(rune(base[0])) {
+			continue
+		}
+		base = strings.ToLower(base[:1]) + base[1:]
+		if has[base] {
+			pairs = append(pairs, [2]string{base, role})
+		}
+	}
+	return pairs
+}
+
+// printSimulatedRoles writes a table of every role as seen normally and under
+// each simulation. With swatches, each color is preceded by a block drawn in
+// it using 24-bit ANSI escapes.
+func printSimulatedRoles(w io.Writer, scheme *m3color.Scheme, sims []m3color.Deficiency, swatches bool) {
+	cell := func(c color.RGBA) string {
+		if !swatches {
+			return colorToHex(c)
+		}
+		return fmt.Sprintf("\x1b[38;2;%d;%d;%dm██\x1b[0m %s", c.R, c.G, c.B, colorToHex(c))
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprint(tw, "ROLE\tNORMAL")
+	for _, d := range sims {
+		fmt.Fprintf(tw, "\t%s", strings.ToUpper(d.String()))
+	}
+	fmt.Fprintln(tw)
+	for _, role := range scheme.Roles() {
+		c := scheme.Color(role)
+		fmt.Fprintf(tw, "%s\t%s", role, cell(c))
+		for _, d := range sims {
+			fmt.Fprintf(tw, "\t%s", cell(m3color.Simulate(c, d)))
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+}
+
+// printConfusedPairs lists the role pairs that are at least minDeltaE apart
+// in normal vision but fall below it under a simulation, and returns how many
+// there are. Pairs already too close in normal vision are a contrast problem
+// for everyone, which audit reports, so they are not counted again.
+func printConfusedPairs(w io.Writer, scheme *m3color.Scheme, sims []m3color.Deficiency, minDeltaE float64) int {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	flagged := 0
+	for _, d := range sims {
+		for _, p := range rolePairs(scheme) {
+			a, b := scheme.Color(p[0]), scheme.Color(p[1])
+			normal := m3color.DeltaE(a, b)
+			simulated := m3color.DeltaE(m3color.Simulate(a, d), m3color.Simulate(b, d))
+			if normal < minDeltaE || simulated >= minDeltaE {
+				continue
+			}
+			if flagged == 0 {
+				fmt.Fprintln(tw, "DEFICIENCY\tROLES\tΔE NORMAL\tΔE SIMULATED")
+			}
+			flagged++
+			fmt.Fprintf(tw, "%s\t%s / %s\t%.1f\t%.1f\n", d, p[0], p[1], normal, simulated)
+		}
+	}
+	if flagged == 0 {
+		fmt.Fprintf(tw, "All role pairs stay at ΔE %g or more.\n", minDeltaE)
+	}
+	tw.Flush()
+	return flagged
+}
+
+// isTerminal reports whether f is a character device, such as a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
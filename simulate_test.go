@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+
+	"material-gtk/pkg/m3color"
+)
+
+func TestPrintConfusedPairs(t *testing.T) {
+	opts := loadTestOptions(t, "", "-rgb", "28,32,39")
+	spec, err := opts.spec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	scheme, err := spec.scheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, minDeltaE := range []float64{10, 30, 60} {
+		var buf bytes.Buffer
+		flagged := printConfusedPairs(&buf, scheme, m3color.Deficiencies, minDeltaE)
+
+		// Every flagged pair is apart in normal vision and close when
+		// simulated; pairs already too close are left to audit.
+		want := 0
+		for _, d := range m3color.Deficiencies {
+			for _, p := range rolePairs(scheme) {
+				a, b := scheme.Color(p[0]), scheme.Color(p[1])
+				normal := m3color.DeltaE(a, b)
+				simulated := m3color.DeltaE(m3color.Simulate(a, d), m3color.Simulate(b, d))
+				if normal >= minDeltaE && simulated < minDeltaE {
+					want++
+				}
+			}
+		}
+		if flagged != want {
+			t.Errorf("ΔE %g: flagged %d pairs, want %d:\n%s", minDeltaE, flagged, want, buf.String())
+		}
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if flagged == 0 {
+			continue
+		}
+		if len(lines) != flagged+1 {
+			t.Errorf("ΔE %g: %d lines for %d pairs:\n%s", minDeltaE, len(lines), flagged, buf.String())
+			continue
+		}
+		for _, line := range lines[1:] {
+			fields := strings.Fields(line)
+			normal, _ := strconv.ParseFloat(fields[len(fields)-2], 64)
+			simulated, _ := strconv.ParseFloat(fields[len(fields)-1], 64)
+			if normal < minDeltaE-0.05 || simulated >= minDeltaE {
+				t.Errorf("ΔE %g: flagged %q", minDeltaE, line)
+			}
+		}
+	}
+
+	// Above every normal-vision difference, nothing is left to flag.
+	var buf bytes.Buffer
+	if flagged := printConfusedPairs(&buf, scheme, m3color.Deficiencies, 1000); flagged != 0 {
+		t.Errorf("flagged %d pairs that are already too close in normal vision:\n%s", flagged, buf.String())
+	}
+	if !strings.Contains(buf.String(), "All role pairs") {
+		t.Errorf("output %q", buf.String())
+	}
+}
+
+func TestOnRolePairs(t *testing.T) {
+	opts := loadTestOptions(t, "", "-rgb", "28,32,39", "-custom", "link=#1a73e8")
+	spec, err := opts.spec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	scheme, err := spec.scheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+	has := make(map[[2]string]bool)
+	for _, p := range onRolePairs(scheme) {
+		has[p] = true
+	}
+	for _, p := range [][2]string{{"primary", "onPrimary"}, {"base", "onBase"}, {"surfaceVariant", "onSurfaceVariant"}, {"linkContainer", "onLinkContainer"}} {
+		if !has[p] {
+			t.Errorf("onRolePairs lacks %v", p)
+		}
+	}
+}
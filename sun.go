@@ -0,0 +1,53 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	julianUnixEpoch = 2440587.5 // Julian date of 1970-01-01T00:00Z
+	julian2000      = 2451545.0 // Julian date of 2000-01-01T12:00Z
+)
+
+func toJulian(t time.Time) float64 {
+	return float64(t.Unix())/86400 + julianUnixEpoch
+}
+
+func fromJulian(j float64) time.Time {
+	return time.Unix(int64(math.Round((j-julianUnixEpoch)*86400)), 0)
+}
+
+// sunTimes computes sunrise and sunset on the day of t for a location, using
+// the sunrise equation (accurate to about a minute). Latitude is positive
+// north, longitude positive east. When the sun does not cross the horizon
+// that day, ok is false and polarDay tells whether it stays up.
+func sunTimes(t time.Time, latitude, longitude float64) (sunrise, sunset time.Time, polarDay, ok bool) {
+	rad := math.Pi / 180
+
+	// Mean solar noon for the local calendar day
+	noon := time.Date(t.Year(), t.Month(), t.Day(), 12, 0, 0, 0, t.Location())
+	n := math.Round(toJulian(noon) - julian2000 + 0.0008)
+	meanNoon := n - longitude/360
+
+	anomaly := math.Mod(357.5291+0.98560028*meanNoon, 360)
+	center := 1.9148*math.Sin(anomaly*rad) + 0.0200*math.Sin(2*anomaly*rad) + 0.0003*math.Sin(3*anomaly*rad)
+	eclipticLong := math.Mod(anomaly+center+180+102.9372, 360)
+	transit := julian2000 + meanNoon + 0.0053*math.Sin(anomaly*rad) - 0.0069*math.Sin(2*eclipticLong*rad)
+
+	declination := math.Asin(math.Sin(eclipticLong*rad) * math.Sin(23.4397*rad))
+	// -0.833° accounts for refraction and the size of the solar disc
+	cosHourAngle := (math.Sin(-0.833*rad) - math.Sin(latitude*rad)*math.Sin(declination)) /
+		(math.Cos(latitude*rad) * math.Cos(declination))
+	switch {
+	case cosHourAngle < -1:
+		return time.Time{}, time.Time{}, true, false
+	case cosHourAngle > 1:
+		return time.Time{}, time.Time{}, false, false
+	}
+
+	hourAngle := math.Acos(cosHourAngle) / rad
+	sunrise = fromJulian(transit - hourAngle/360).In(t.Location())
+	sunset = fromJulian(transit + hourAngle/360).In(t.Location())
+	return sunrise, sunset, false, true
+}
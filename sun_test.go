@@ -0,0 +1,71 @@
+package main
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestSunTimes(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Reference times from published almanac tables, to the minute.
+	tests := []struct {
+		name                string
+		latitude, longitude float64
+		sunrise, sunset     time.Time
+	}{
+		{
+			"London midsummer", 51.5074, -0.1278,
+			time.Date(2024, 6, 21, 4, 43, 0, 0, london),
+			time.Date(2024, 6, 21, 21, 21, 0, 0, london),
+		},
+		{
+			"New York midwinter", 40.7128, -74.0060,
+			time.Date(2024, 12, 21, 7, 16, 0, 0, newYork),
+			time.Date(2024, 12, 21, 16, 32, 0, 0, newYork),
+		},
+	}
+	const tolerance = 2 * time.Minute
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rise, set, _, ok := sunTimes(tt.sunrise, tt.latitude, tt.longitude)
+			if !ok {
+				t.Fatal("sunTimes reports no sunrise")
+			}
+			if d := rise.Sub(tt.sunrise).Abs(); d > tolerance {
+				t.Errorf("sunrise %v, want %v ±%v", rise, tt.sunrise, tolerance)
+			}
+			if d := set.Sub(tt.sunset).Abs(); d > tolerance {
+				t.Errorf("sunset %v, want %v ±%v", set, tt.sunset, tolerance)
+			}
+		})
+	}
+}
+
+func TestSunTimesPolar(t *testing.T) {
+	const latitude, longitude = 78.0, 15.0
+	tests := []struct {
+		name     string
+		t        time.Time
+		polarDay bool
+	}{
+		{"midnight sun", time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC), true},
+		{"polar night", time.Date(2024, 12, 21, 12, 0, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, polarDay, ok := sunTimes(tt.t, latitude, longitude)
+			if ok || polarDay != tt.polarDay {
+				t.Errorf("sunTimes = polarDay %v, ok %v; want polarDay %v, ok false", polarDay, ok, tt.polarDay)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image/color"
+	"math"
+	"os"
+	"slices"
+	"strings"
+
+	"golang.org/x/term"
+
+	"material-gtk/pkg/m3color"
+)
+
+// tuiCellWidth is the width of one role swatch, in columns.
+const tuiCellWidth = 26
+
+// tuiState is what the tui subcommand lets the user adjust.
+type tuiState struct {
+	seed    m3color.HCT
+	rgb     color.RGBA // the seed as given, used until seed is edited
+	edited  bool
+	variant int // index into m3color.VariantNames
+	mode    m3color.Mode
+}
+
+func newTUIState(spec themeSpec) tuiState {
+	return tuiState{
+		seed:    m3color.RGBToHCT(spec.Seed.R, spec.Seed.G, spec.Seed.B),
+		rgb:     spec.Seed,
+		variant: max(slices.Index(m3color.VariantNames, spec.Variant), 0),
+		mode:    spec.Mode,
+	}
+}
+
+// seedRGB returns the seed color. The original RGB is kept until the hue,
+// chroma or tone changes, as the HCT round trip is lossy.
+func (s tuiState) seedRGB() color.RGBA {
+	if !s.edited {
+		return s.rgb
+	}
+	return s.seed.ToRGB()
+}
+
+// handleKey applies one key press to s. It reports whether the loop is done
+// and, if so, whether the user confirmed.
+func (s *tuiState) handleKey(key string) (done, confirmed bool) {
+	seed := s.seed
+	switch key {
+	case "\r", "\n":
+		return true, true
+	case "q", "Q", "\x1b", "\x03":
+		return true, false
+	case "\x1b[D", "h":
+		s.seed.Hue = math.Mod(s.seed.Hue+355, 360)
+	case "\x1b[C", "l":
+		s.seed.Hue = math.Mod(s.seed.Hue+5, 360)
+	case "H":
+		s.seed.Hue = math.Mod(s.seed.Hue+359, 360)
+	case "L":
+		s.seed.Hue = math.Mod(s.seed.Hue+1, 360)
+	case "c":
+		s.seed.Chroma = max(s.seed.Chroma-5, 0)
+	case "C":
+		s.seed.Chroma = min(s.seed.Chroma+5, 120)
+	case "\x1b[B", "j", "t":
+		s.seed.Tone = max(s.seed.Tone-5, 0)
+	case "\x1b[A", "k", "T":
+		s.seed.Tone = min(s.seed.Tone+5, 100)
+	case "v":
+		s.variant = (s.variant + 1) % len(m3color.VariantNames)
+	case "V":
+		s.variant = (s.variant + len(m3color.VariantNames) - 1) % len(m3color.VariantNames)
+	case "m", "M":
+		if s.mode == m3color.Light {
+			s.mode = m3color.Dark
+		} else {
+			s.mode = m3color.Light
+		}
+	}
+	if s.seed != seed {
+		s.edited = true
+	}
+	return false, false
+}
+
+// runTUI implements the tui subcommand: an interactive, full-screen preview
+// of the scheme roles. The seed's hue, chroma and tone, the variant and the
+// mode can be changed from the keyboard; Enter generates the theme with the
+// remaining options (so -apply, -output and the config's outputs apply) and
+// q or Escape quits without doing anything.
+func runTUI(args []string) error {
+	fs := flag.NewFlagSet("tui", flag.ExitOnError)
+	var opts options
+	opts.register(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s tui [options] [R,G,B]\n\nOptions:\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if _, err := opts.loadWithSeedArg(fs); err != nil {
+		return err
+	}
+	if opts.seed == "" && opts.seedFrom == "" {
+		return fmt.Errorf("tui needs a seed (R,G,B, -rgb or -seed-from)")
+	}
+	spec, err := opts.spec()
+	if err != nil {
+		return err
+	}
+
+	in, out := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	if !term.IsTerminal(in) || !term.IsTerminal(out) {
+		return fmt.Errorf("tui needs a terminal")
+	}
+
+	state := newTUIState(spec)
+	confirmed, err := runTUILoop(in, out, spec, &state)
+	if err != nil || !confirmed {
+		return err
+	}
+
+	opts.seed = colorToHex(state.seedRGB())
+	opts.seedFrom = ""
+	opts.variant = m3color.VariantNames[state.variant]
+	opts.mode = state.mode.String()
+	return generate(&opts)
+}
+
+// runTUILoop puts the terminal into raw mode on the alternate screen and
+// handles keys until the user confirms or quits. The terminal is restored
+// before it returns.
+func runTUILoop(in, out int, spec themeSpec, state *tuiState) (confirmed bool, err error) {
+	saved, err := term.MakeRaw(in)
+	if err != nil {
+		return false, fmt.Errorf("failed to enter raw mode: %w", err)
+	}
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	defer func() {
+		fmt.Print("\x1b[?25h\x1b[?1049l")
+		term.Restore(in, saved)
+	}()
+
+	buf := make([]byte, 16)
+	for {
+		width, _, err := term.GetSize(out)
+		if err != nil || width <= 0 {
+			width = 80
+		}
+		screen, err := renderTUI(spec, *state, width)
+		if err != nil {
+			return false, err
+		}
+		os.Stdout.WriteString(screen)
+
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return false, err
+		}
+		if done, confirmed := state.handleKey(string(buf[:n])); done {
+			return confirmed, nil
+		}
+	}
+}
+
+// renderTUI draws the whole screen for state: the seed, the current settings,
+// every scheme role as a 24-bit color cell labelled in its on-role, and the
+// key help. Lines end in \r\n as the terminal is in raw mode.
+func renderTUI(spec themeSpec, state tuiState, width int) (string, error) {
+	seed := state.seedRGB()
+	spec.Seed = seed
+	spec.Variant = m3color.VariantNames[state.variant]
+	spec.Mode = state.mode
+	scheme, err := spec.scheme()
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString("\x1b[H\x1b[2J")
+	fmt.Fprintf(&b, "%s %s  H%.0f C%.0f T%.0f\r\n", ansiCell(seed, seed, "      "), colorToHex(seed), state.seed.Hue, state.seed.Chroma, state.seed.Tone)
+	fmt.Fprintf(&b, "Variant: %s   Mode: %s\r\n\r\n", spec.Variant, spec.Mode)
+
+	// Label each role in its on-role where there is one, otherwise in black
+	// or white by tone.
+	labels := make(map[string]string)
+	for _, p := range onRolePairs(scheme) {
+		labels[p[0]], labels[p[1]] = p[1], p[0]
+	}
+	columns := max(width/tuiCellWidth, 1)
+	for i, role := range scheme.Roles() {
+		bg := scheme.Color(role)
+		fg := color.RGBA{0, 0, 0, 255}
+		if on, ok := labels[role]; ok {
+			fg = scheme.Color(on)
+		} else if m3color.RGBToHCT(bg.R, bg.G, bg.B).Tone < 60 {
+			fg = color.RGBA{255, 255, 255, 255}
+		}
+		name := role
+		if len(name) > tuiCellWidth-2 {
+			name = name[:tuiCellWidth-2]
+		}
+		b.WriteString(ansiCell(bg, fg, fmt.Sprintf(" %-*s ", tuiCellWidth-2, name)))
+		if (i+1)%columns == 0 {
+			b.WriteString("\r\n")
+		}
+	}
+	b.WriteString("\r\n\r\n")
+	b.WriteString("←/→ h/l hue ∓5 (H/L ∓1)   c/C chroma ∓5   ↓/↑ j/k t/T tone ∓5\r\n")
+	b.WriteString("v/V variant   m mode   Enter generate   q/Esc quit\r\n")
+	return b.String(), nil
+}
+
+// ansiCell returns text drawn in fg on bg using 24-bit ANSI escapes.
+func ansiCell(bg, fg color.RGBA, text string) string {
+	return fmt.Sprintf("\x1b[48;2;%d;%d;%dm\x1b[38;2;%d;%d;%dm%s\x1b[0m", bg.R, bg.G, bg.B, fg.R, fg.G, fg.B, text)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"image/color"
+	"strings"
+	"testing"
+
+	"material-gtk/pkg/m3color"
+)
+
+func TestTUIKeepsSeed(t *testing.T) {
+	// These seeds change in an RGB -> HCT -> RGB round trip.
+	for _, seed := range []color.RGBA{{200, 100, 50, 255}, {12, 200, 100, 255}} {
+		state := newTUIState(themeSpec{Seed: seed, Variant: "tonal_spot", Mode: m3color.Light})
+		// Keys that leave hue, chroma and tone alone.
+		for _, key := range []string{"v", "V", "m", "m", "x"} {
+			if done, _ := state.handleKey(key); done {
+				t.Fatalf("key %q ended the loop", key)
+			}
+		}
+		if got := state.seedRGB(); got != seed {
+			t.Errorf("seed %v became %v without edits", seed, got)
+		}
+		if done, confirmed := state.handleKey("\r"); !done || !confirmed {
+			t.Errorf("Enter = %v, %v", done, confirmed)
+		}
+		if got := state.seedRGB(); got != seed {
+			t.Errorf("seed %v confirmed as %v", seed, got)
+		}
+
+		state.handleKey("l")
+		if !state.edited || state.seedRGB() != state.seed.ToRGB() {
+			t.Errorf("editing the hue of %v kept the original seed", seed)
+		}
+	}
+}
+
+func TestTUIHandleKey(t *testing.T) {
+	start := tuiState{seed: m3color.HCT{Hue: 2, Chroma: 3, Tone: 98}, variant: 0, mode: m3color.Light}
+	n := len(m3color.VariantNames)
+	tests := []struct {
+		keys    string
+		want    tuiState
+		done    bool
+		confirm bool
+	}{
+		{"h", tuiState{seed: m3color.HCT{Hue: 357, Chroma: 3, Tone: 98}}, false, false},
+		{"\x1b[C", tuiState{seed: m3color.HCT{Hue: 7, Chroma: 3, Tone: 98}}, false, false},
+		{"H", tuiState{seed: m3color.HCT{Hue: 1, Chroma: 3, Tone: 98}}, false, false},
+		{"L", tuiState{seed: m3color.HCT{Hue: 3, Chroma: 3, Tone: 98}}, false, false},
+		{"c", tuiState{seed: m3color.HCT{Hue: 2, Chroma: 0, Tone: 98}}, false, false},
+		{"C", tuiState{seed: m3color.HCT{Hue: 2, Chroma: 8, Tone: 98}}, false, false},
+		{"j", tuiState{seed: m3color.HCT{Hue: 2, Chroma: 3, Tone: 93}}, false, false},
+		{"\x1b[A", tuiState{seed: m3color.HCT{Hue: 2, Chroma: 3, Tone: 100}}, false, false},
+		{"v", tuiState{seed: start.seed, variant: 1}, false, false},
+		{"V", tuiState{seed: start.seed, variant: n - 1}, false, false},
+		{"m", tuiState{seed: start.seed, mode: m3color.Dark}, false, false},
+		{"\n", tuiState{seed: start.seed}, true, true},
+		{"q", tuiState{seed: start.seed}, true, false},
+		{"\x1b", tuiState{seed: start.seed}, true, false},
+		{"\x03", tuiState{seed: start.seed}, true, false},
+	}
+	for _, tt := range tests {
+		state := start
+		done, confirmed := state.handleKey(tt.keys)
+		if done != tt.done || confirmed != tt.confirm {
+			t.Errorf("%q: done, confirmed = %v, %v, want %v, %v", tt.keys, done, confirmed, tt.done, tt.confirm)
+		}
+		if state.seed != tt.want.seed || state.variant != tt.want.variant {
+			t.Errorf("%q: state = %+v, want %+v", tt.keys, state, tt.want)
+		}
+		if tt.want.mode == m3color.Dark && state.mode != m3color.Dark {
+			t.Errorf("%q: mode = %v, want dark", tt.keys, state.mode)
+		}
+		if state.edited != (tt.want.seed != start.seed) {
+			t.Errorf("%q: edited = %v", tt.keys, state.edited)
+		}
+	}
+}
+
+func TestRenderTUI(t *testing.T) {
+	seed := color.RGBA{200, 100, 50, 255}
+	spec := themeSpec{Seed: seed, Variant: "tonal_spot", Mode: m3color.Dark}
+	state := newTUIState(spec)
+	screen, err := renderTUI(spec, state, 80)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(screen, "\x1b[H\x1b[2J") {
+		t.Error("screen does not start by clearing the terminal")
+	}
+	for _, want := range []string{
+		"#c86432",
+		"Variant: tonal_spot   Mode: dark\r\n",
+		"Enter generate",
+	} {
+		if !strings.Contains(screen, want) {
+			t.Errorf("screen lacks %q", want)
+		}
+	}
+
+	// Each role is a cell in its own color, labelled in its on-role.
+	spec.Seed = seed
+	scheme, err := spec.scheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, role := range []string{"primary", "onPrimary", "surface"} {
+		on := "on" + strings.ToUpper(role[:1]) + role[1:]
+		if role == "onPrimary" {
+			on = "primary"
+		}
+		cell := ansiCell(scheme.Color(role), scheme.Color(on), " "+role+strings.Repeat(" ", tuiCellWidth-2-len(role))+" ")
+		if !strings.Contains(screen, cell) {
+			t.Errorf("screen lacks the %s cell", role)
+		}
+	}
+
+	// 80 columns fit three cells per row; no line holds more.
+	for _, line := range strings.Split(screen, "\r\n") {
+		if cells := strings.Count(line, "\x1b[0m"); cells > 3 {
+			t.Errorf("a line holds %d cells at width 80", cells)
+		}
+	}
+	if got := strings.Count(screen, "\x1b[48;2;"); got != len(scheme.Roles())+1 {
+		t.Errorf("%d cells, want %d roles plus the seed", got, len(scheme.Roles()))
+	}
+}
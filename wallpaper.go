@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"material-gtk/pkg/m3color"
+)
+
+// seedSource locates the wallpaper a seed color is derived from.
+type seedSource interface {
+	// Wallpaper returns the path of the current wallpaper image.
+	Wallpaper() (string, error)
+	// WatchPaths returns the files whose changes may mean the wallpaper
+	// changed. Directories are watched as a whole.
+	WatchPaths() ([]string, error)
+}
+
+// seedSourceNames lists the values accepted by -seed-from. All but accent
+// derive the seed from a wallpaper.
+var seedSourceNames = []string{"wallpaper", "gnome", "swww", "hyprpaper", "accent"}
+
+// newSeedSource builds the wallpaper source for a -seed-from value.
+func newSeedSource(name, wallpaper string) (seedSource, error) {
+	switch name {
+	case "accent":
+		return nil, fmt.Errorf("-seed-from accent reads the desktop accent color, not a wallpaper")
+	case "wallpaper":
+		if wallpaper == "" {
+			return nil, fmt.Errorf("-seed-from wallpaper needs -wallpaper")
+		}
+		return fileSource{path: wallpaper}, nil
+	case "gnome":
+		return gnomeSource{}, nil
+	case "swww":
+		return swwwSource{}, nil
+	case "hyprpaper":
+		return hyprpaperSource{}, nil
+	}
+	return nil, fmt.Errorf("unknown seed source %q (valid: %s)", name, strings.Join(seedSourceNames, ", "))
+}
+
+// sourceSeed derives the seed color from the configured -seed-from source.
+func (o *options) sourceSeed() (color.RGBA, error) {
+	if o.seedFrom == "accent" {
+		return accentColor()
+	}
+
+	src, err := newSeedSource(o.seedFrom, o.wallpaper)
+	if err != nil {
+		return color.RGBA{}, err
+	}
+	path, err := src.Wallpaper()
+	if err != nil {
+		return color.RGBA{}, err
+	}
+	return seedFromImage(path)
+}
+
+// fileSource is a fixed wallpaper file.
+type fileSource struct {
+	path string
+}
+
+func (f fileSource) Wallpaper() (string, error) { return f.path, nil }
+
+func (f fileSource) WatchPaths() ([]string, error) { return []string{f.path}, nil }
+
+// gnomeSource reads org.gnome.desktop.background picture-uri. Changes are
+// noticed through the dconf database file, which is rewritten on every write.
+type gnomeSource struct{}
+
+func (gnomeSource) Wallpaper() (string, error) {
+	key := "picture-uri"
+	if out, err := exec.Command("gsettings", "get", "org.gnome.desktop.interface", "color-scheme").Output(); err == nil &&
+		strings.Contains(string(out), "prefer-dark") {
+		key = "picture-uri-dark"
+	}
+
+	out, err := exec.Command("gsettings", "get", "org.gnome.desktop.background", key).Output()
+	if err != nil {
+		return "", fmt.Errorf("gsettings get %s failed: %w", key, err)
+	}
+	uri := strings.Trim(strings.TrimSpace(string(out)), "'")
+	u, err := url.Parse(uri)
+	if err != nil || (u.Scheme != "file" && u.Scheme != "") || u.Path == "" {
+		return "", fmt.Errorf("unsupported wallpaper URI %q", uri)
+	}
+	return u.Path, nil
+}
+
+func (g gnomeSource) WatchPaths() ([]string, error) {
+	configDir, err := userConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	paths := []string{filepath.Join(configDir, "dconf", "user")}
+	if wp, err := g.Wallpaper(); err == nil {
+		paths = append(paths, wp)
+	}
+	return paths, nil
+}
+
+// swwwSource asks the swww daemon which image it displays. swww rewrites its
+// per-output cache files whenever a new image is set.
+type swwwSource struct{}
+
+func (swwwSource) Wallpaper() (string, error) {
+	out, err := exec.Command("swww", "query").Output()
+	if err != nil {
+		return "", fmt.Errorf("swww query failed: %w", err)
+	}
+	// e.g. "eDP-1: 1920x1080, scale: 1, currently displaying: image: /path.png"
+	for _, line := range strings.Split(string(out), "\n") {
+		if _, path, ok := strings.Cut(line, "image: "); ok {
+			return strings.TrimSpace(path), nil
+		}
+	}
+	return "", fmt.Errorf("swww is not displaying an image")
+}
+
+func (s swwwSource) WatchPaths() ([]string, error) {
+	cacheDir := os.Getenv("XDG_CACHE_HOME")
+	if cacheDir == "" {
+		home, err := homeDir()
+		if err != nil {
+			return nil, err
+		}
+		cacheDir = filepath.Join(home, ".cache")
+	}
+	paths := []string{filepath.Join(cacheDir, "swww")}
+	if wp, err := s.Wallpaper(); err == nil {
+		paths = append(paths, wp)
+	}
+	return paths, nil
+}
+
+// hyprpaperSource reads the first wallpaper from hyprpaper.conf.
+type hyprpaperSource struct{}
+
+func hyprpaperConfigPath() (string, error) {
+	configDir, err := userConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "hypr", "hyprpaper.conf"), nil
+}
+
+func (hyprpaperSource) Wallpaper() (string, error) {
+	path, err := hyprpaperConfigPath()
+	if err != nil {
+		return "", err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	// wallpaper = [monitor],/path/to/image
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok || strings.TrimSpace(key) != "wallpaper" {
+			continue
+		}
+		_, image, _ := strings.Cut(value, ",")
+		if image = strings.TrimSpace(image); image != "" {
+			return expandHome(image)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("no wallpaper set in %s", path)
+}
+
+func (h hyprpaperSource) WatchPaths() ([]string, error) {
+	path, err := hyprpaperConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	paths := []string{path}
+	if wp, err := h.Wallpaper(); err == nil {
+		paths = append(paths, wp)
+	}
+	return paths, nil
+}
+
+// seedFromImage picks a seed color from an image, in the spirit of Material's
+// quantize-and-score: colors are bucketed, near-greys are dropped, and the
+// bucket that best balances population and chroma wins.
+func seedFromImage(path string) (color.RGBA, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return color.RGBA{}, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return dominantColor(img), nil
+}
+
+// fallbackSeed is Material's default seed (Google blue), used when an image
+// has no usable color.
+var fallbackSeed = color.RGBA{0x42, 0x85, 0xf4, 255}
+
+func dominantColor(img image.Image) color.RGBA {
+	type bucket struct {
+		r, g, b, n int
+	}
+	var buckets [1 << 12]bucket // 4 bits per channel
+
+	// Sample at most ~128x128 pixels; wallpapers are large.
+	bounds := img.Bounds()
+	step := max(1, max(bounds.Dx(), bounds.Dy())/128)
+	total := 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+		for x := bounds.Min.X; x < bounds.Max.X; x += step {
+			c := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+			if c.A < 255 {
+				continue
+			}
+			b := &buckets[int(c.R>>4)<<8|int(c.G>>4)<<4|int(c.B>>4)]
+			b.r += int(c.R)
+			b.g += int(c.G)
+			b.b += int(c.B)
+			b.n++
+			total++
+		}
+	}
+	if total == 0 {
+		return fallbackSeed
+	}
+
+	best, bestScore := fallbackSeed, math.Inf(-1)
+	for _, b := range buckets {
+		if b.n == 0 {
+			continue
+		}
+		c := color.RGBA{uint8(b.r / b.n), uint8(b.g / b.n), uint8(b.b / b.n), 255}
+		hct := m3color.RGBToHCT(c.R, c.G, c.B)
+		if hct.Chroma < 15 || float64(b.n)/float64(total) < 0.01 {
+			continue
+		}
+
+		// Score weights as in Material's Score: proportion dominates, chroma
+		// above 48 is rewarded and below 48 gently penalised.
+		proportion := float64(b.n) / float64(total) * 100 * 0.7
+		chromaWeight := 0.1
+		if hct.Chroma >= 48 {
+			chromaWeight = 0.3
+		}
+		score := proportion + (hct.Chroma-48)*chromaWeight
+		if score > bestScore {
+			best, bestScore = c, score
+		}
+	}
+	return best
+}
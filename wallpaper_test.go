@@ -0,0 +1,174 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// stripedImage has one column per count, with each color filling as many
+// columns as its count.
+func stripedImage(stripes []color.RGBA, counts []int) *image.RGBA {
+	width := 0
+	for _, n := range counts {
+		width += n
+	}
+	img := image.NewRGBA(image.Rect(0, 0, width, 10))
+	x := 0
+	for i, c := range stripes {
+		for end := x + counts[i]; x < end; x++ {
+			for y := 0; y < 10; y++ {
+				img.SetRGBA(x, y, c)
+			}
+		}
+	}
+	return img
+}
+
+// writeTestPNG writes img to a PNG file in a temp directory.
+func writeTestPNG(t *testing.T, dir, name string, img image.Image) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDominantColor(t *testing.T) {
+	grey := color.RGBA{0x80, 0x80, 0x80, 255}
+	red := color.RGBA{0xd0, 0x20, 0x20, 255}
+	blue := color.RGBA{0x20, 0x40, 0xd0, 255}
+	tests := []struct {
+		name string
+		img  image.Image
+		want color.RGBA
+	}{
+		{"greys are skipped", stripedImage([]color.RGBA{grey, red, blue}, []int{70, 20, 10}), red},
+		{"larger share wins", stripedImage([]color.RGBA{red, blue}, []int{30, 70}), blue},
+		{"specks are skipped", stripedImage([]color.RGBA{grey, red}, []int{995, 5}), fallbackSeed},
+		{"only greys", stripedImage([]color.RGBA{grey}, []int{100}), fallbackSeed},
+		{"transparent", image.NewRGBA(image.Rect(0, 0, 10, 10)), fallbackSeed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dominantColor(tt.img); got != tt.want {
+				t.Errorf("dominantColor = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSeedFromImage(t *testing.T) {
+	dir := t.TempDir()
+	red := color.RGBA{0xd0, 0x20, 0x20, 255}
+	path := writeTestPNG(t, dir, "red.png", stripedImage([]color.RGBA{red}, []int{100}))
+	if got, err := seedFromImage(path); err != nil || got != red {
+		t.Errorf("seedFromImage = %v, %v; want %v", got, err, red)
+	}
+
+	notImage := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(notImage, []byte("not an image"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := seedFromImage(notImage); err == nil || !strings.Contains(err.Error(), "failed to decode") {
+		t.Errorf("seedFromImage(text file) = %v, want a decode error", err)
+	}
+	if _, err := seedFromImage(filepath.Join(dir, "missing.png")); err == nil {
+		t.Error("seedFromImage(missing file) succeeded")
+	}
+}
+
+func TestNewSeedSource(t *testing.T) {
+	tests := []struct {
+		name, wallpaper string
+		want            seedSource
+		err             string
+	}{
+		{"wallpaper", "/pics/a.png", fileSource{path: "/pics/a.png"}, ""},
+		{"wallpaper", "", nil, "needs -wallpaper"},
+		{"gnome", "", gnomeSource{}, ""},
+		{"swww", "", swwwSource{}, ""},
+		{"hyprpaper", "", hyprpaperSource{}, ""},
+		{"accent", "", nil, "not a wallpaper"},
+		{"kde", "", nil, "unknown seed source"},
+	}
+	for _, tt := range tests {
+		got, err := newSeedSource(tt.name, tt.wallpaper)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("newSeedSource(%q, %q) = %v, want an error containing %q", tt.name, tt.wallpaper, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("newSeedSource(%q, %q) = %#v, %v; want %#v", tt.name, tt.wallpaper, got, err, tt.want)
+		}
+	}
+}
+
+func TestHyprpaperSource(t *testing.T) {
+	home, config := t.TempDir(), t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", config)
+	path := filepath.Join(config, "hypr", "hyprpaper.conf")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		conf string
+		want string
+		err  string
+	}{
+		{"preload = ~/pics/a.png\nwallpaper = DP-1,~/pics/a.png\nwallpaper = ,/pics/b.png\n", filepath.Join(home, "pics", "a.png"), ""},
+		{"wallpaper = , /pics/b.png\n", "/pics/b.png", ""},
+		{"preload = /pics/a.png\n", "", "no wallpaper set"},
+	}
+	for _, tt := range tests {
+		if err := os.WriteFile(path, []byte(tt.conf), 0644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := hyprpaperSource{}.Wallpaper()
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Wallpaper() with %q = %q, %v; want an error containing %q", tt.conf, got, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Wallpaper() with %q = %q, %v; want %q", tt.conf, got, err, tt.want)
+		}
+	}
+
+	paths, err := hyprpaperSource{}.WatchPaths()
+	if err != nil || len(paths) != 1 || paths[0] != path {
+		t.Errorf("WatchPaths() = %q, %v; want just %s", paths, err, path)
+	}
+}
+
+func TestSourceSeedPrecedence(t *testing.T) {
+	red := color.RGBA{0xd0, 0x20, 0x20, 255}
+	img := writeTestPNG(t, t.TempDir(), "red.png", stripedImage([]color.RGBA{red}, []int{100}))
+
+	opts := loadTestOptions(t, "seed = \"#336699\"\n", "-seed-from", "wallpaper", "-wallpaper", img)
+	spec, err := opts.spec()
+	if err != nil || spec.Seed != red {
+		t.Errorf("spec().Seed = %v, %v; want the wallpaper's %v", spec.Seed, err, red)
+	}
+
+	opts = loadTestOptions(t, "seed_from = \"wallpaper\"\nwallpaper = \""+img+"\"\n", "-rgb", "#336699")
+	spec, err = opts.spec()
+	if want := (color.RGBA{0x33, 0x66, 0x99, 255}); err != nil || spec.Seed != want {
+		t.Errorf("spec().Seed = %v, %v; want the -rgb %v", spec.Seed, err, want)
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"image/color"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// runWatch implements the watch subcommand: it regenerates and re-applies the
+// theme whenever the -seed-from source reports a different wallpaper.
+func runWatch(args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	var opts options
+	opts.register(fs)
+	debounce := fs.Duration("debounce", 500*time.Millisecond, "Wait this long after the last change before regenerating")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s watch -seed-from SOURCE [options]\n\nOptions:\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if _, err := opts.load(fs); err != nil {
+		return err
+	}
+	if opts.seedFrom == "" {
+		return fmt.Errorf("watch needs -seed-from (or seed_from in the config): %v", seedSourceNames)
+	}
+	src, err := newSeedSource(opts.seedFrom, opts.wallpaper)
+	if err != nil {
+		return err
+	}
+	opts.apply = true
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to start inotify watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	w := &wallpaperWatcher{
+		opts:    opts,
+		src:     src,
+		watcher: watcher,
+		dirs:    make(map[string]bool),
+		targets: make(map[string]bool),
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return w.run(ctx, *debounce)
+}
+
+type wallpaperWatcher struct {
+	opts    options
+	src     seedSource
+	watcher *fsnotify.Watcher
+
+	dirs    map[string]bool // directories registered with inotify
+	targets map[string]bool // paths whose events trigger a regeneration
+
+	last     color.RGBA
+	haveLast bool
+}
+
+func (w *wallpaperWatcher) run(ctx context.Context, debounce time.Duration) error {
+	w.regenerate()
+	if err := w.updateWatches(); err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-w.watcher.Events:
+			if !ok {
+				return nil
+			}
+			if ev.Op == fsnotify.Chmod || !w.matches(ev.Name) {
+				continue
+			}
+			timer.Reset(debounce)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.Printf("Warning: watch error: %v", err)
+		case <-timer.C:
+			w.regenerate()
+			if err := w.updateWatches(); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+		}
+	}
+}
+
+// matches reports whether an event for path concerns the wallpaper source.
+func (w *wallpaperWatcher) matches(path string) bool {
+	return w.targets[path] || w.targets[filepath.Dir(path)]
+}
+
+// updateWatches re-resolves the source's paths, which change when the
+// wallpaper itself changes, and watches their parent directories so that
+// files replaced by rename are still noticed.
+func (w *wallpaperWatcher) updateWatches() error {
+	paths, err := w.src.WatchPaths()
+	if err != nil {
+		return fmt.Errorf("failed to resolve watch paths: %w", err)
+	}
+
+	targets := make(map[string]bool)
+	dirs := make(map[string]bool)
+	for _, p := range paths {
+		p = filepath.Clean(p)
+		targets[p] = true
+		if info, err := os.Stat(p); err == nil && info.IsDir() {
+			dirs[p] = true
+		} else {
+			dirs[filepath.Dir(p)] = true
+		}
+	}
+
+	for dir := range dirs {
+		if w.dirs[dir] {
+			continue
+		}
+		if err := w.watcher.Add(dir); err != nil {
+			log.Printf("Warning: cannot watch %s: %v", dir, err)
+			delete(dirs, dir)
+		}
+	}
+	for dir := range w.dirs {
+		if !dirs[dir] {
+			w.watcher.Remove(dir)
+		}
+	}
+
+	w.dirs, w.targets = dirs, targets
+	return nil
+}
+
+// regenerate derives the seed from the current wallpaper and, if it changed,
+// regenerates and applies the theme. Failures are logged so that watching
+// continues.
+func (w *wallpaperWatcher) regenerate() {
+	path, err := w.src.Wallpaper()
+	if err != nil {
+		log.Printf("Warning: %v", err)
+		return
+	}
+	seed, err := seedFromImage(path)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+		return
+	}
+	if w.haveLast && seed == w.last {
+		return
+	}
+
+	fmt.Printf("🖼️  Wallpaper %s → seed %s\n", path, colorToHex(seed))
+	opts := w.opts
+	opts.seedFrom = ""
+	opts.seed = colorToHex(seed)
+	if err := generate(&opts); err != nil {
+		log.Printf("Warning: %v", err)
+		return
+	}
+	w.last, w.haveLast = seed, true
+}
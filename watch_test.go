@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"image/color"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// newTestWatcher watches src with a real inotify watcher.
+func newTestWatcher(t *testing.T, opts options, src seedSource) *wallpaperWatcher {
+	t.Helper()
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		t.Skipf("inotify unavailable: %v", err)
+	}
+	t.Cleanup(func() { watcher.Close() })
+	return &wallpaperWatcher{
+		opts:    opts,
+		src:     src,
+		watcher: watcher,
+		dirs:    make(map[string]bool),
+		targets: make(map[string]bool),
+	}
+}
+
+func TestUpdateWatches(t *testing.T) {
+	dirA, dirB := t.TempDir(), t.TempDir()
+	wallpaperA := filepath.Join(dirA, "a.png")
+	if err := os.WriteFile(wallpaperA, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	w := newTestWatcher(t, options{}, fileSource{path: wallpaperA})
+
+	if err := w.updateWatches(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{dirA}; !reflect.DeepEqual(w.watcher.WatchList(), want) {
+		t.Errorf("watching %q, want %q", w.watcher.WatchList(), want)
+	}
+	if !w.matches(wallpaperA) || w.matches(filepath.Join(dirA, "other.png")) {
+		t.Errorf("matches: the wallpaper should match and its neighbours not")
+	}
+
+	// An event for the wallpaper arrives through the parent directory.
+	if err := os.WriteFile(wallpaperA, []byte("changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case ev := <-w.watcher.Events:
+		if !w.matches(ev.Name) {
+			t.Errorf("event for %s does not match", ev.Name)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no inotify event for the wallpaper")
+	}
+
+	// A wallpaper in another directory moves the watch there.
+	w.src = fileSource{path: filepath.Join(dirB, "b.png")}
+	if err := w.updateWatches(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{dirB}; !reflect.DeepEqual(w.watcher.WatchList(), want) {
+		t.Errorf("watching %q, want %q", w.watcher.WatchList(), want)
+	}
+	if w.matches(wallpaperA) {
+		t.Errorf("the old wallpaper still matches")
+	}
+
+	// A directory path is watched as a whole.
+	w.src = fileSource{path: dirA}
+	if err := w.updateWatches(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{dirA}; !reflect.DeepEqual(w.watcher.WatchList(), want) {
+		t.Errorf("watching %q, want %q", w.watcher.WatchList(), want)
+	}
+	if !w.matches(filepath.Join(dirA, "any.png")) {
+		t.Errorf("files in a watched directory should match")
+	}
+}
+
+func TestWatchRegenerates(t *testing.T) {
+	dir := t.TempDir()
+	red := color.RGBA{0xd0, 0x20, 0x20, 255}
+	blue := color.RGBA{0x20, 0x40, 0xd0, 255}
+	wallpaper := writeTestPNG(t, dir, "wallpaper.png", stripedImage([]color.RGBA{red}, []int{100}))
+	out := filepath.Join(t.TempDir(), "scheme.json")
+
+	opts := loadTestOptions(t, "", "-seed-from", "wallpaper", "-wallpaper", wallpaper, "-format", "json", "-output", out)
+	w := newTestWatcher(t, opts, fileSource{path: wallpaper})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- w.run(ctx, 20*time.Millisecond) }()
+	defer func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("run: %v", err)
+		}
+	}()
+
+	waitForSeed := func(want color.RGBA) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		var doc schemeDocument
+		for time.Now().Before(deadline) {
+			if data, err := os.ReadFile(out); err == nil && json.Unmarshal(data, &doc) == nil && doc.Seed == colorToHex(want) {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("seed is %q, want %s", doc.Seed, colorToHex(want))
+	}
+	waitForSeed(red)
+
+	// Replace the wallpaper by rename, as wallpaper tools do.
+	next := writeTestPNG(t, t.TempDir(), "next.png", stripedImage([]color.RGBA{blue}, []int{100}))
+	if err := os.Rename(next, wallpaper); err != nil {
+		t.Fatal(err)
+	}
+	waitForSeed(blue)
+}